@@ -0,0 +1,211 @@
+package main
+
+import (
+	"fmt"
+
+	"github.com/laracarvalho/rogolike/ecs"
+)
+
+// ActionResult is what came of an actor trying an action.
+// Cost is how long the action took when it succeeded. Alternate, when set, is
+// the action that should be tried instead, such as an attack when a move bumps
+// into an enemy.
+type ActionResult struct {
+	Succeeded bool
+	Cost      int
+	Alternate Action
+}
+
+// Success is the result of an action which went through and took cost time.
+func Success(cost int) ActionResult {
+	return ActionResult{Succeeded: true, Cost: cost}
+}
+
+// Failure is the result of an action which couldn't be done. It takes no time.
+func Failure() ActionResult {
+	return ActionResult{}
+}
+
+// Alternate is the result of an action which turned out to be another action.
+func Alternate(action Action) ActionResult {
+	return ActionResult{Alternate: action}
+}
+
+// Action is something an actor intends to do on its turn. The player's input
+// and the monsters' AI both produce actions, and PerformAction resolves them,
+// so the rules of the game live in one place for everybody.
+type Action interface {
+	Perform(g *Game, actor *ecs.QueryResult) ActionResult
+}
+
+// PerformAction has the actor try the action, following any alternates, and
+// returns the final result.
+func PerformAction(g *Game, actor *ecs.QueryResult, action Action) ActionResult {
+	for {
+		result := action.Perform(g, actor)
+		if result.Alternate == nil {
+			return result
+		}
+		action = result.Alternate
+	}
+}
+
+// TakeTurn performs the action for the actor and, if it went through, puts the
+// actor back in the scheduler for when the action is done. It reports whether
+// the actor's turn is over. Stunned actors can do nothing but wait, and
+// slowed ones take twice as long over everything.
+func TakeTurn(g *Game, actor *ecs.QueryResult, action Action) bool {
+	if actor.Entity.HasComponent(stunned) {
+		if actor.Entity.HasComponent(player) {
+			LogMessage(g, "You are stunned!")
+		}
+		action = WaitAction{}
+	}
+	result := PerformAction(g, actor, action)
+	if !result.Succeeded {
+		return false
+	}
+
+	actorSpeed := NormalSpeed
+	if s, ok := actor.Entity.GetComponentData(speed); ok {
+		actorSpeed = s.(*Speed).Speed
+	}
+	if actor.Entity.HasComponent(slowed) {
+		actorSpeed /= 2
+	}
+	if id, ok := g.Scheduler.Peek(); ok && id == actor.Entity.ID {
+		g.Scheduler.Next()
+	}
+	g.Scheduler.Add(actor.Entity.ID, Delay(result.Cost, actorSpeed))
+	return true
+}
+
+// ActorAt returns the living player or monster standing at x, y, or nil.
+func ActorAt(g *Game, x int, y int) *ecs.QueryResult {
+	for _, tag := range []string{"players", "monsters"} {
+		for _, result := range g.World.Query(g.WorldTags[tag]) {
+			pos := result.Components[position].(*Position)
+			if pos.X == x && pos.Y == y && result.Components[health].(*Health).CurrentHealth > 0 {
+				return result
+			}
+		}
+	}
+	return nil
+}
+
+// IsHostile reports whether the two actors fight each other. Monsters band
+// together against the player.
+func IsHostile(a *ecs.QueryResult, b *ecs.QueryResult) bool {
+	return a.Entity.HasComponent(player) != b.Entity.HasComponent(player)
+}
+
+// MoveAction steps the actor by DX, DY. Stepping into an enemy attacks it.
+type MoveAction struct {
+	DX int
+	DY int
+}
+
+func (a MoveAction) Perform(g *Game, actor *ecs.QueryResult) ActionResult {
+	level := g.Map.CurrentLevel
+	pos := actor.Components[position].(*Position)
+
+	if !g.Movement.CanStep(level, pos.X, pos.Y, a.DX, a.DY) {
+		//A wall or a corner we may not cut past
+		return Failure()
+	}
+
+	tx := pos.X + a.DX
+	ty := pos.Y + a.DY
+	if other := ActorAt(g, tx, ty); other != nil {
+		if IsHostile(actor, other) {
+			return Alternate(AttackAction{Target: Position{X: tx, Y: ty}})
+		}
+		return Failure()
+	}
+
+	level.Tiles[level.GetIndexFromXY(pos.X, pos.Y)].Blocked = false
+	pos.X = tx
+	pos.Y = ty
+	level.Tiles[level.GetIndexFromXY(tx, ty)].Blocked = true
+
+	if actor.Entity.HasComponent(player) {
+		level.PlayerVisible.Compute(level, pos.X, pos.Y, playerSightRadius)
+		level.RevealSeen()
+	}
+
+	return Success(ActionCostMove)
+}
+
+// AttackAction has the actor swing its melee weapon at whoever stands on Target.
+type AttackAction struct {
+	Target Position
+}
+
+func (a AttackAction) Perform(g *Game, actor *ecs.QueryResult) ActionResult {
+	level := g.Map.CurrentLevel
+	pos := actor.Components[position].(*Position)
+
+	if !g.Movement.IsAdjacent(level, pos, &a.Target) {
+		return Failure()
+	}
+	if ActorAt(g, a.Target.X, a.Target.Y) == nil {
+		return Failure()
+	}
+
+	cost := AttackCost(CombatWeapon(g, actor.Entity))
+	if result := AttackSystem(g, pos, &a.Target); result.Fumble {
+		cost += fumbleCost
+	}
+	return Success(cost)
+}
+
+// WaitAction lets a turn go by.
+type WaitAction struct{}
+
+func (a WaitAction) Perform(g *Game, actor *ecs.QueryResult) ActionResult {
+	return Success(ActionCostWait)
+}
+
+// DescendAction takes the player down the stairs they stand on to a new level,
+// one deeper in the dungeon. Everything on the level they leave is gone for
+// good; only what they carry goes with them.
+type DescendAction struct{}
+
+func (a DescendAction) Perform(g *Game, actor *ecs.QueryResult) ActionResult {
+	level := g.Map.CurrentLevel
+	pos := actor.Components[position].(*Position)
+
+	if !actor.Entity.HasComponent(player) {
+		return Failure()
+	}
+	if !level.IsStairs(pos.X, pos.Y) {
+		LogMessage(g, "There are no stairs down here.")
+		return Failure()
+	}
+
+	for _, result := range g.World.Query(ecs.BuildTag(position)) {
+		if result.Entity.HasComponent(player) {
+			continue
+		}
+		g.Scheduler.Remove(result.Entity.ID)
+		g.World.DisposeEntity(result.Entity)
+	}
+	g.DangerZones = g.DangerZones[:0]
+
+	next := NewLevel(level.Depth + 1)
+	g.Map.Dungeons[0].Levels = append(g.Map.Dungeons[0].Levels, next)
+	g.Map.CurrentLevel = next
+
+	pos.X, pos.Y = next.Rooms[0].Center()
+	next.Tiles[next.GetIndexFromXY(pos.X, pos.Y)].Blocked = true
+	PopulateLevel(g.World, next, g.Loot)
+	for _, m := range g.World.Query(g.WorldTags["monsters"]) {
+		g.Scheduler.Add(m.Entity.ID, 0)
+	}
+
+	next.PlayerVisible.Compute(next, pos.X, pos.Y, playerSightRadius)
+	next.RevealSeen()
+	LogMessage(g, fmt.Sprintf("You go down the stairs to depth %d.", next.Depth))
+
+	return Success(ActionCostMove)
+}
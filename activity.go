@@ -0,0 +1,172 @@
+package main
+
+import (
+	"fmt"
+
+	"github.com/laracarvalho/rogolike/ecs"
+)
+
+// activityDelay is the number of ticks between the turns of an activity, so
+// the player can watch it happen.
+const activityDelay = 4
+
+// Activity is a command that keeps the player busy for several turns, such as
+// travelling to a tile. It hands out one action per turn until it is done or
+// something interrupts it.
+type Activity interface {
+	// NextAction returns the player's next action, or nil when the activity is done.
+	NextAction(g *Game, player *ecs.QueryResult) Action
+}
+
+// activityWatch remembers what the player was aware of when an activity
+// started, so the activity can stop when that changes.
+type activityWatch struct {
+	seen   map[ecs.EntityID]bool
+	items  map[ecs.EntityID]bool
+	health int
+	timer  int
+}
+
+// StartActivity sets the player off on the activity.
+func StartActivity(g *Game, a Activity) {
+	g.Activity = a
+	g.activityWatch = &activityWatch{seen: visibleMonsters(g), items: visibleItems(g), health: playerHealth(g)}
+}
+
+// playerHealth returns the player's current health.
+func playerHealth(g *Game) int {
+	hp := 0
+	for _, p := range g.World.Query(g.WorldTags["players"]) {
+		hp = p.Components[health].(*Health).CurrentHealth
+	}
+	return hp
+}
+
+// StopActivity ends the current activity, telling the player why if reason isn't empty.
+func StopActivity(g *Game, reason string) {
+	if g.Activity == nil {
+		return
+	}
+	g.Activity = nil
+	g.activityWatch = nil
+	if reason != "" {
+		LogMessage(g, reason)
+	}
+}
+
+// visibleMonsters returns the monsters the player can see right now.
+func visibleMonsters(g *Game) map[ecs.EntityID]bool {
+	level := g.Map.CurrentLevel
+	seen := make(map[ecs.EntityID]bool)
+	for _, m := range g.World.Query(g.WorldTags["monsters"]) {
+		pos := m.Components[position].(*Position)
+		if level.PlayerCanSee(pos.X, pos.Y) && m.Components[health].(*Health).CurrentHealth > 0 {
+			seen[m.Entity.ID] = true
+		}
+	}
+	return seen
+}
+
+// activityInterruption returns why the activity should stop, or an empty
+// string if it can go on.
+func activityInterruption(g *Game) string {
+	level := g.Map.CurrentLevel
+
+	hp := playerHealth(g)
+	if hp < g.activityWatch.health {
+		return "You are hurt!"
+	}
+	g.activityWatch.health = hp
+
+	for _, m := range g.World.Query(g.WorldTags["monsters"]) {
+		pos := m.Components[position].(*Position)
+		if g.activityWatch.seen[m.Entity.ID] || m.Components[health].(*Health).CurrentHealth <= 0 {
+			continue
+		}
+		if level.PlayerCanSee(pos.X, pos.Y) {
+			return fmt.Sprintf("You see a %s.", m.Components[name].(*Name).Label)
+		}
+	}
+
+	for id := range visibleItems(g) {
+		if !g.activityWatch.items[id] {
+			g.activityWatch.items[id] = true
+			if e := g.World.GetEntityByID(id); e != nil {
+				return fmt.Sprintf("You see %s.", ItemName(g, e.Entity))
+			}
+		}
+	}
+	return ""
+}
+
+// nextActivityAction returns the action the current activity wants the player
+// to take this tick, if it is time for one.
+func nextActivityAction(g *Game, player *ecs.QueryResult) Action {
+	w := g.activityWatch
+	w.timer++
+	if w.timer < activityDelay {
+		return nil
+	}
+	w.timer = 0
+
+	if reason := activityInterruption(g); reason != "" {
+		StopActivity(g, reason)
+		return nil
+	}
+
+	action := g.Activity.NextAction(g, player)
+	if action == nil {
+		StopActivity(g, "")
+	}
+	return action
+}
+
+// RevealedCost is the TileCost of walking through the parts of the level the
+// player knows about. Tiles they have never seen can't be entered.
+func RevealedCost(level Level, x int, y int) int {
+	if !level.Tiles[level.GetIndexFromXY(x, y)].IsRevealed {
+		return -1
+	}
+	return WalkableCost(level, x, y)
+}
+
+// TravelActivity walks the player along a path, one step per turn.
+type TravelActivity struct {
+	Path []Position
+	step int
+}
+
+// NewTravelActivity plans a route over known ground from the player's position
+// to the goal. It returns nil if there is no such route.
+func NewTravelActivity(g *Game, from *Position, goal *Position) *TravelActivity {
+	astar := AStar{
+		Rules: g.Movement,
+		Cost:  WithPenalties(RevealedCost, OccupiedPenalty(allyPenalty)),
+	}
+	path := astar.GetPath(g.Map.CurrentLevel, from, goal)
+	if len(path) < 2 {
+		return nil
+	}
+	return &TravelActivity{Path: path, step: 1}
+}
+
+func (t *TravelActivity) NextAction(g *Game, player *ecs.QueryResult) Action {
+	if t.step >= len(t.Path) {
+		return nil
+	}
+
+	pos := player.Components[position].(*Position)
+	if !pos.IsEqual(&t.Path[t.step-1]) {
+		//Something moved us off the path
+		return nil
+	}
+
+	next := t.Path[t.step]
+	if ActorAt(g, next.X, next.Y) != nil {
+		StopActivity(g, "Something is in the way.")
+		return nil
+	}
+
+	t.step++
+	return MoveAction{DX: next.X - pos.X, DY: next.Y - pos.Y}
+}
@@ -0,0 +1,195 @@
+package main
+
+import (
+	"image/color"
+)
+
+// RarityTier is how special a piece of equipment is: how many affixes it has.
+type RarityTier int
+
+const (
+	Common RarityTier = iota
+	Magic
+	Rare
+)
+
+func (r RarityTier) String() string {
+	switch r {
+	case Magic:
+		return "Magic"
+	case Rare:
+		return "Rare"
+	}
+	return "Common"
+}
+
+// Rarity is the rarity tier of an item.
+type Rarity struct {
+	Tier RarityTier
+}
+
+// rarityColors are what the names of items of each tier are shown in.
+var rarityColors = map[RarityTier]color.Color{
+	Common: color.White,
+	Magic:  color.RGBA{R: 110, G: 150, B: 255, A: 255},
+	Rare:   color.RGBA{R: 255, G: 220, B: 80, A: 255},
+}
+
+// AffixKind is what kind of equipment an affix can be rolled on.
+type AffixKind int
+
+const (
+	WeaponAffix AffixKind = iota
+	ArmorAffix
+)
+
+// Affix is a prefix or suffix that adds to the stats of a piece of equipment
+// and to its name. It can only be rolled from MinDepth down; Weight is its
+// chance against the other affixes which could be rolled.
+type Affix struct {
+	Name          string
+	Prefix        bool
+	Kind          AffixKind
+	Weight        int
+	MinDepth      int
+	MinimumDamage int
+	MaximumDamage int
+	ToHitBonus    int
+	Defense       int
+	ArmorClass    int
+}
+
+// Affixes are all the affixes equipment can be rolled with.
+var Affixes = []Affix{
+	{Name: "Sharp", Prefix: true, Kind: WeaponAffix, Weight: 4, MinDepth: 1, MaximumDamage: 2},
+	{Name: "Flaming", Prefix: true, Kind: WeaponAffix, Weight: 2, MinDepth: 1, MinimumDamage: 1, MaximumDamage: 3},
+	{Name: "Vicious", Prefix: true, Kind: WeaponAffix, Weight: 2, MinDepth: 3, MinimumDamage: 2, MaximumDamage: 5},
+	{Name: "of Accuracy", Kind: WeaponAffix, Weight: 4, MinDepth: 1, ToHitBonus: 2},
+	{Name: "of Precision", Kind: WeaponAffix, Weight: 2, MinDepth: 3, ToHitBonus: 4},
+	{Name: "of Slaying", Kind: WeaponAffix, Weight: 1, MinDepth: 5, MaximumDamage: 6, ToHitBonus: 1},
+	{Name: "Sturdy", Prefix: true, Kind: ArmorAffix, Weight: 4, MinDepth: 1, Defense: 1},
+	{Name: "Reinforced", Prefix: true, Kind: ArmorAffix, Weight: 2, MinDepth: 2, Defense: 2, ArmorClass: 1},
+	{Name: "Runed", Prefix: true, Kind: ArmorAffix, Weight: 1, MinDepth: 4, Defense: 3, ArmorClass: 2},
+	{Name: "of Deflection", Kind: ArmorAffix, Weight: 4, MinDepth: 1, ArmorClass: 1},
+	{Name: "of Warding", Kind: ArmorAffix, Weight: 2, MinDepth: 3, Defense: 1, ArmorClass: 2},
+}
+
+// rollRarity picks the rarity tier of a piece of equipment found at the given
+// depth. Deeper down, better things are found.
+func rollRarity(depth int) RarityTier {
+	common := 70
+	magic := 25 + 3*depth
+	rare := 5 + 2*depth
+	roll := GetRandomInt(common + magic + rare)
+	switch {
+	case roll < common:
+		return Common
+	case roll < common+magic:
+		return Magic
+	}
+	return Rare
+}
+
+// rollAffix picks an affix of the kind which can be found at the depth, or
+// returns false if there is none.
+func rollAffix(kind AffixKind, prefix bool, depth int) (Affix, bool) {
+	total := 0
+	for _, a := range Affixes {
+		if a.Kind == kind && a.Prefix == prefix && depth >= a.MinDepth {
+			total += a.Weight
+		}
+	}
+	if total == 0 {
+		return Affix{}, false
+	}
+
+	roll := GetRandomInt(total)
+	for _, a := range Affixes {
+		if a.Kind != kind || a.Prefix != prefix || depth < a.MinDepth {
+			continue
+		}
+		roll -= a.Weight
+		if roll < 0 {
+			return a, true
+		}
+	}
+	return Affix{}, false
+}
+
+// canHaveAffixes reports whether affixes can be rolled on items from the template.
+// Rings and uniques are special enough already.
+func canHaveAffixes(t ItemTemplate) bool {
+	if t.Unique || t.Slot == SlotNone || t.Slot == SlotLeftRing || t.Slot == SlotRightRing {
+		return false
+	}
+	return t.Weapon != nil || t.Armor != nil
+}
+
+// GenerateEquipment rolls the rarity and affixes of a piece of equipment found
+// at the given depth, and returns the template of the item with them. Magic
+// items get a prefix or a suffix, rare ones both.
+func GenerateEquipment(t ItemTemplate, depth int) ItemTemplate {
+	if !canHaveAffixes(t) {
+		return t
+	}
+
+	kind := ArmorAffix
+	if t.Weapon != nil {
+		kind = WeaponAffix
+	}
+
+	affixes := make([]Affix, 0, 2)
+	t.Rarity = rollRarity(depth)
+	switch t.Rarity {
+	case Magic:
+		if a, ok := rollAffix(kind, GetDiceRoll(2) == 1, depth); ok {
+			affixes = append(affixes, a)
+		}
+	case Rare:
+		for _, prefix := range []bool{true, false} {
+			if a, ok := rollAffix(kind, prefix, depth); ok {
+				affixes = append(affixes, a)
+			}
+		}
+	}
+	if len(affixes) == 0 {
+		t.Rarity = Common
+		return t
+	}
+
+	label := t.Name
+	t.BaseName = t.Name
+	if t.Weapon != nil {
+		wpn := *t.Weapon
+		t.Weapon = &wpn
+	}
+	if t.Armor != nil {
+		arm := *t.Armor
+		t.Armor = &arm
+	}
+	for _, a := range affixes {
+		if a.Prefix {
+			label = a.Name + " " + label
+		} else {
+			label = label + " " + a.Name
+		}
+		if t.Weapon != nil {
+			t.Weapon.MinimumDamage += a.MinimumDamage
+			t.Weapon.MaximumDamage += a.MaximumDamage
+			t.Weapon.ToHitBonus += a.ToHitBonus
+		}
+		if t.Armor != nil {
+			t.Armor.Defense += a.Defense
+			t.Armor.ArmorClass += a.ArmorClass
+		}
+	}
+
+	t.Name = label
+	if t.Weapon != nil {
+		t.Weapon.Name = label
+	}
+	if t.Armor != nil {
+		t.Armor.Name = label
+	}
+	return t
+}
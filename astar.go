@@ -1,210 +1,164 @@
 package main
 
 import (
-	"errors"
-	"reflect"
+	"container/heap"
 )
 
-// Node represents a given point on a map
+// node represents a given point on a map that is waiting in the open set.
+// index is the tile index of the point on the level
 // g is the total distance of the node from the start
-// h is the estimated distance of the node from the ending
 // f is the total value of the node (g + h)
 type node struct {
-	Parent   *node
-	Position *Position
-	g        int
-	h        int
-	f        int
+	index int
+	g     int
+	f     int
 }
 
-func (n *node) isEqual(other *node) bool {
-	return n.Position.IsEqual(other.Position)
-}
+// openSet is a binary heap of nodes ordered by their f value. Ties are broken
+// in favour of the node furthest from the start, which keeps the search
+// heading toward the goal instead of fanning out.
+type openSet []node
 
-func newNode(parent *node, position *Position) *node {
-	n := node{}
-	n.Parent = parent
-	n.Position = position
-	n.g = 0
-	n.h = 0
-	n.f = 0
+func (o openSet) Len() int { return len(o) }
 
-	return &n
+func (o openSet) Less(i, j int) bool {
+	if o[i].f == o[j].f {
+		return o[i].g > o[j].g
+	}
+	return o[i].f < o[j].f
 }
 
-func reverseSlice(data interface{}) {
-	value := reflect.ValueOf(data)
-	if value.Kind() != reflect.Slice {
-		panic(errors.New("data must be a slice type"))
-	}
-	valueLen := value.Len()
-	for i := 0; i <= int((valueLen-1)/2); i++ {
-		reverseIndex := valueLen - 1 - i
-		tmp := value.Index(reverseIndex).Interface()
-		value.Index(reverseIndex).Set(value.Index(i))
-		value.Index(i).Set(reflect.ValueOf(tmp))
-	}
+func (o openSet) Swap(i, j int) { o[i], o[j] = o[j], o[i] }
+
+func (o *openSet) Push(x interface{}) {
+	*o = append(*o, x.(node))
 }
 
-func isInSlice(s []*node, target *node) bool {
-	for _, n := range s {
-		if n.isEqual(target) {
-			return true
-		}
-	}
-	return false
+func (o *openSet) Pop() interface{} {
+	old := *o
+	n := old[len(old)-1]
+	*o = old[:len(old)-1]
+	return n
 }
 
 // AStar implements the AStar Algorithm.
-type AStar struct{}
+// MaxNodes is the search budget: the number of nodes the search may expand
+// before giving up. Zero means the search is unbounded.
+// Rules decides which neighbours are reachable and what each step costs, and
+// Cost weights the tiles themselves. A nil Cost means WalkableCost.
+type AStar struct {
+	MaxNodes int
+	Rules    MovementRules
+	Cost     TileCost
+}
 
 // GetPath takes a level, the starting position and an ending position (the goal) and returns
-// a list of Positions which is the path between the points.
+// a list of Positions which is the path between the points, including both ends.
+// It returns nil if there is no path or the search budget ran out.
 func (as AStar) GetPath(level Level, start *Position, end *Position) []Position {
-	gd := NewGameData()
+	if !level.InBounds(start.X, start.Y) || !level.InBounds(end.X, end.Y) {
+		return nil
+	}
 
-	openList := make([]*node, 0)
-	closedList := make([]*node, 0)
+	cost := as.Cost
+	if cost == nil {
+		cost = WalkableCost
+	}
 
-	//Create our starting point
-	startNode := newNode(nil, start)
-	startNode.g = 0
-	startNode.h = 0
-	startNode.f = 0
+	size := len(level.Tiles)
+	startIndex := level.GetIndexFromXY(start.X, start.Y)
+	endIndex := level.GetIndexFromXY(end.X, end.Y)
+
+	//Scores and parents are keyed by tile index, -1 means not seen yet
+	gScore := make([]int, size)
+	cameFrom := make([]int, size)
+	closed := make([]bool, size)
+	for i := range gScore {
+		gScore[i] = -1
+		cameFrom[i] = -1
+	}
 
-	//Create this node just for ease of dropping into our isEqual function to see if we are at the end
-	endNodePlaceholder := newNode(nil, end)
+	open := &openSet{}
+	gScore[startIndex] = 0
+	heap.Push(open, node{index: startIndex, g: 0, f: as.Rules.Heuristic(start, end)})
 
-	openList = append(openList, startNode)
+	expanded := 0
+	for open.Len() > 0 {
+		current := heap.Pop(open).(node)
 
-	for {
-		if len(openList) == 0 {
-			break
-		}
-		//Get the current node
-		currentNode := openList[0]
-		currentIndex := 0
-
-		//Get the node with the smallest f value
-		for index, item := range openList {
-			if item.f < currentNode.f {
-				currentNode = item
-				currentIndex = index
-			}
+		//A cheaper route to this tile was found after this entry was queued
+		if closed[current.index] || current.g > gScore[current.index] {
+			continue
 		}
 
-		//Move from open to closed list
-		openList = append(openList[:currentIndex], openList[currentIndex+1:]...)
-		closedList = append(closedList, currentNode)
-
-		//Check to see if we reached our end
-		//If so, we are done here
-		if currentNode.isEqual(endNodePlaceholder) {
-			path := make([]Position, 0)
-			current := currentNode
-			for {
-				if current == nil {
-					break
-				}
-				path = append(path, *current.Position)
-				current = current.Parent
-			}
-			//Reverse the Path and Return it
-			reverseSlice(path)
-			return path
+		if current.index == endIndex {
+			return buildPath(cameFrom, endIndex, level.Width)
 		}
 
-		//Ok, if we are here, we are not finished yet
-
-		edges := make([]*node, 0)
-		//Now we get each node in the four cardinal directions
-		//Note:  If you wish to add Diagonal movement, you can do so by getting all 8 positions
-		if currentNode.Position.Y > 0 {
-			tile := level.Tiles[level.GetIndexFromXY(currentNode.Position.X, currentNode.Position.Y-1)]
-			if tile.TileType != WALL {
-				//The location is in the map bounds and is walkable
-				upNodePosition := Position{
-					X: currentNode.Position.X,
-					Y: currentNode.Position.Y - 1,
-				}
-				newNode := newNode(currentNode, &upNodePosition)
-				edges = append(edges, newNode)
-
-			}
-
+		closed[current.index] = true
+		expanded++
+		if as.MaxNodes > 0 && expanded >= as.MaxNodes {
+			break
 		}
-		if currentNode.Position.Y < gd.ScreenHeight {
-			tile := level.Tiles[level.GetIndexFromXY(currentNode.Position.X, currentNode.Position.Y+1)]
-			if tile.TileType != WALL {
-				//The location is in the map bounds and is walkable
-				downNodePosition := Position{
-					X: currentNode.Position.X,
-					Y: currentNode.Position.Y + 1,
-				}
-				newNode := newNode(currentNode, &downNodePosition)
-				edges = append(edges, newNode)
 
-			}
-
-		}
-		if currentNode.Position.X > 0 {
-			tile := level.Tiles[level.GetIndexFromXY(currentNode.Position.X-1, currentNode.Position.Y)]
-			if tile.TileType != WALL {
-				//The location is in the map bounds and is walkable
-				leftNodePosition := Position{
-					X: currentNode.Position.X - 1,
-					Y: currentNode.Position.Y,
-				}
-				newNode := newNode(currentNode, &leftNodePosition)
-				edges = append(edges, newNode)
+		x := current.index % level.Width
+		y := current.index / level.Width
 
+		//Now we get each neighbour the movement rules allow
+		for _, dir := range as.Rules.Directions() {
+			if !as.Rules.CanStep(level, x, y, dir.X, dir.Y) {
+				continue
 			}
 
-		}
-		if currentNode.Position.X < gd.ScreenWidth {
-			tile := level.Tiles[level.GetIndexFromXY(currentNode.Position.X+1, currentNode.Position.Y)]
-			if tile.TileType != WALL {
-				//The location is in the map bounds and is walkable
-				rightNodePosition := Position{
-					X: currentNode.Position.X + 1,
-					Y: currentNode.Position.Y,
-				}
-				newNode := newNode(currentNode, &rightNodePosition)
-				edges = append(edges, newNode)
-
+			nx := x + dir.X
+			ny := y + dir.Y
+			next := level.GetIndexFromXY(nx, ny)
+			if closed[next] {
+				continue
 			}
 
-		}
+			tileCost := cost(level, nx, ny)
+			if tileCost < 0 {
+				continue
+			}
 
-		//Now we iterate through the edges and put them in the open list.
-		for _, edge := range edges {
-			if isInSlice(closedList, edge) {
+			g := current.g + tileCost*as.Rules.StepCost(dir.X, dir.Y)/straightStepCost
+			if gScore[next] != -1 && g >= gScore[next] {
 				continue
 			}
 
-			edge.g = currentNode.g + 1
-			edge.h = edge.Position.GetManhattanDistance(endNodePlaceholder.Position)
-			edge.f = edge.g + edge.h
+			gScore[next] = g
+			cameFrom[next] = current.index
+			nextPosition := Position{X: nx, Y: ny}
+			heap.Push(open, node{index: next, g: g, f: g + as.Rules.Heuristic(&nextPosition, end)})
+		}
+	}
 
-			if isInSlice(openList, edge) {
-				//Loop through and check g values
-				isFurther := false
-				for _, n := range openList {
-					if edge.g > n.g {
-						isFurther = true
-						break
-					}
-				}
+	return nil
+}
 
-				if isFurther {
-					continue
-				}
+// cardinalDirections are the offsets to the four orthogonal neighbours of a tile.
+var cardinalDirections = []Position{
+	{X: 0, Y: -1},
+	{X: 0, Y: 1},
+	{X: -1, Y: 0},
+	{X: 1, Y: 0},
+}
 
-			}
-			openList = append(openList, edge)
-		}
+// buildPath walks the parent indices back from the end tile and returns the
+// path in start to end order. width is the width of the level.
+func buildPath(cameFrom []int, endIndex int, width int) []Position {
+	length := 0
+	for idx := endIndex; idx != -1; idx = cameFrom[idx] {
+		length++
+	}
 
+	path := make([]Position, length)
+	i := length - 1
+	for idx := endIndex; idx != -1; idx = cameFrom[idx] {
+		path[i] = Position{X: idx % width, Y: idx / width}
+		i--
 	}
 
-	return nil
+	return path
 }
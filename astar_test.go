@@ -0,0 +1,215 @@
+package main
+
+import (
+	"math/rand"
+	"testing"
+)
+
+// testLevel builds a level from rows of text: '#' is wall, anything else floor.
+func testLevel(rows []string) Level {
+	l := Level{Width: len(rows[0]), Height: len(rows)}
+	l.Tiles = make([]*MapTile, l.Width*l.Height)
+	for y, row := range rows {
+		for x, c := range row {
+			tile := &MapTile{TileType: FLOOR}
+			if c == '#' {
+				tile.TileType = WALL
+				tile.Blocked = true
+			}
+			l.Tiles[l.GetIndexFromXY(x, y)] = tile
+		}
+	}
+	return l
+}
+
+// randomLevel builds a width by height level walled in all round, with about
+// walls percent of the inside walled too.
+func randomLevel(r *rand.Rand, width int, height int, walls int) Level {
+	rows := make([]string, height)
+	for y := range rows {
+		row := make([]byte, width)
+		for x := range row {
+			row[x] = '.'
+			if x == 0 || y == 0 || x == width-1 || y == height-1 || r.Intn(100) < walls {
+				row[x] = '#'
+			}
+		}
+		rows[y] = string(row)
+	}
+	return testLevel(rows)
+}
+
+// mazeLevel builds a width by height maze by a randomised depth first search,
+// with corridors one tile wide. Both dimensions should be odd.
+func mazeLevel(r *rand.Rand, width int, height int) Level {
+	grid := make([][]byte, height)
+	for y := range grid {
+		grid[y] = make([]byte, width)
+		for x := range grid[y] {
+			grid[y][x] = '#'
+		}
+	}
+
+	stack := []Position{{X: 1, Y: 1}}
+	grid[1][1] = '.'
+	for len(stack) > 0 {
+		cur := stack[len(stack)-1]
+		next := make([]Position, 0, 4)
+		for _, d := range cardinalDirections {
+			nx, ny := cur.X+2*d.X, cur.Y+2*d.Y
+			if nx > 0 && ny > 0 && nx < width-1 && ny < height-1 && grid[ny][nx] == '#' {
+				next = append(next, Position{X: nx, Y: ny})
+			}
+		}
+		if len(next) == 0 {
+			stack = stack[:len(stack)-1]
+			continue
+		}
+		n := next[r.Intn(len(next))]
+		grid[(cur.Y+n.Y)/2][(cur.X+n.X)/2] = '.'
+		grid[n.Y][n.X] = '.'
+		stack = append(stack, n)
+	}
+
+	rows := make([]string, height)
+	for y := range grid {
+		rows[y] = string(grid[y])
+	}
+	return testLevel(rows)
+}
+
+// bruteForceCost finds the cheapest cost from start to end by relaxing every
+// step over and over until nothing improves, or returns -1 if end can't be
+// reached.
+func bruteForceCost(level Level, rules MovementRules, cost TileCost, start Position, end Position) int {
+	dist := make([]int, len(level.Tiles))
+	for i := range dist {
+		dist[i] = -1
+	}
+	dist[level.GetIndexFromXY(start.X, start.Y)] = 0
+
+	for changed := true; changed; {
+		changed = false
+		for y := 0; y < level.Height; y++ {
+			for x := 0; x < level.Width; x++ {
+				here := dist[level.GetIndexFromXY(x, y)]
+				if here < 0 {
+					continue
+				}
+				for _, dir := range rules.Directions() {
+					if !rules.CanStep(level, x, y, dir.X, dir.Y) {
+						continue
+					}
+					c := cost(level, x+dir.X, y+dir.Y)
+					if c < 0 {
+						continue
+					}
+					next := level.GetIndexFromXY(x+dir.X, y+dir.Y)
+					g := here + c*rules.StepCost(dir.X, dir.Y)/straightStepCost
+					if dist[next] < 0 || g < dist[next] {
+						dist[next] = g
+						changed = true
+					}
+				}
+			}
+		}
+	}
+	return dist[level.GetIndexFromXY(end.X, end.Y)]
+}
+
+// pathCost checks every step of the path is allowed and returns what it costs.
+func pathCost(t *testing.T, level Level, rules MovementRules, cost TileCost, path []Position) int {
+	total := 0
+	for i := 1; i < len(path); i++ {
+		dx, dy := path[i].X-path[i-1].X, path[i].Y-path[i-1].Y
+		if !rules.CanStep(level, path[i-1].X, path[i-1].Y, dx, dy) {
+			t.Fatalf("path steps from %v to %v, which the rules don't allow", path[i-1], path[i])
+		}
+		total += cost(level, path[i].X, path[i].Y) * rules.StepCost(dx, dy) / straightStepCost
+	}
+	return total
+}
+
+var testRules = map[string]MovementRules{
+	"4-way":          {},
+	"8-way":          {Diagonal: true},
+	"8-way cutting":  {Diagonal: true, CornerCutting: true},
+	"octile":         {Diagonal: true, Octile: true},
+	"octile cutting": {Diagonal: true, CornerCutting: true, Octile: true},
+}
+
+func TestAStarMatchesBruteForce(t *testing.T) {
+	r := rand.New(rand.NewSource(1))
+	for name, rules := range testRules {
+		for i := 0; i < 40; i++ {
+			level := randomLevel(r, 20, 15, 30)
+			penalties := make([]int, len(level.Tiles))
+			for j := range penalties {
+				penalties[j] = r.Intn(3) * straightStepCost
+			}
+			cost := WalkableCost
+			if i%2 == 1 {
+				cost = WithPenalties(WalkableCost, func(level Level, x int, y int) int {
+					return penalties[level.GetIndexFromXY(x, y)]
+				})
+			}
+
+			start := Position{X: 1 + r.Intn(18), Y: 1 + r.Intn(13)}
+			end := Position{X: 1 + r.Intn(18), Y: 1 + r.Intn(13)}
+			if level.IsWall(start.X, start.Y) || level.IsWall(end.X, end.Y) {
+				continue
+			}
+
+			want := bruteForceCost(level, rules, cost, start, end)
+			path := AStar{Rules: rules, Cost: cost}.GetPath(level, &start, &end)
+			if want < 0 {
+				if path != nil {
+					t.Errorf("%s: found a path from %v to %v where there is none", name, start, end)
+				}
+				continue
+			}
+			if len(path) == 0 || path[0] != start || path[len(path)-1] != end {
+				t.Errorf("%s: path from %v to %v is %v", name, start, end, path)
+				continue
+			}
+			if got := pathCost(t, level, rules, cost, path); got != want {
+				t.Errorf("%s: path from %v to %v costs %d, the cheapest costs %d", name, start, end, got, want)
+			}
+		}
+	}
+}
+
+func TestAStarBudget(t *testing.T) {
+	level := mazeLevel(rand.New(rand.NewSource(1)), 41, 41)
+	start := Position{X: 1, Y: 1}
+	end := Position{X: 39, Y: 39}
+	if path := (AStar{MaxNodes: 10}).GetPath(level, &start, &end); path != nil {
+		t.Errorf("search with a budget of 10 nodes found a path through a maze: %v", path)
+	}
+	if path := (AStar{}).GetPath(level, &start, &end); path == nil {
+		t.Errorf("unbounded search found no path through the maze")
+	}
+}
+
+func BenchmarkAStar200(b *testing.B) {
+	r := rand.New(rand.NewSource(1))
+	levels := map[string]Level{
+		"open": randomLevel(r, 200, 200, 0),
+		"maze": mazeLevel(r, 199, 199),
+	}
+	start := Position{X: 1, Y: 1}
+	end := Position{X: 197, Y: 197}
+
+	for levelName, level := range levels {
+		for _, rulesName := range []string{"4-way", "octile"} {
+			as := AStar{Rules: testRules[rulesName]}
+			b.Run(levelName+"/"+rulesName, func(b *testing.B) {
+				for i := 0; i < b.N; i++ {
+					if as.GetPath(level, &start, &end) == nil {
+						b.Fatal("no path")
+					}
+				}
+			})
+		}
+	}
+}
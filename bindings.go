@@ -0,0 +1,281 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/hajimehoshi/ebiten/v2"
+)
+
+// Command is something the player can ask for, independent of the key used to ask for it.
+type Command string
+
+const (
+	CommandMoveNorth     Command = "move_north"
+	CommandMoveSouth     Command = "move_south"
+	CommandMoveWest      Command = "move_west"
+	CommandMoveEast      Command = "move_east"
+	CommandMoveNorthWest Command = "move_north_west"
+	CommandMoveNorthEast Command = "move_north_east"
+	CommandMoveSouthWest Command = "move_south_west"
+	CommandMoveSouthEast Command = "move_south_east"
+	CommandWait          Command = "wait"
+	CommandWaitTurns     Command = "wait_turns"
+	CommandRest          Command = "rest"
+	CommandExplore       Command = "explore"
+	CommandPickUp        Command = "pick_up"
+	CommandDrop          Command = "drop"
+	CommandInventory     Command = "inventory"
+	CommandEquip         Command = "equip"
+	CommandUnequip       Command = "unequip"
+	CommandUse           Command = "use"
+	CommandFire          Command = "fire"
+	CommandDescend       Command = "descend"
+	CommandKeyBindings   Command = "key_bindings"
+)
+
+// Commands lists every command in the order the rebinding screen shows them.
+var Commands = []Command{
+	CommandMoveNorth,
+	CommandMoveSouth,
+	CommandMoveWest,
+	CommandMoveEast,
+	CommandMoveNorthWest,
+	CommandMoveNorthEast,
+	CommandMoveSouthWest,
+	CommandMoveSouthEast,
+	CommandWait,
+	CommandWaitTurns,
+	CommandRest,
+	CommandExplore,
+	CommandPickUp,
+	CommandDrop,
+	CommandInventory,
+	CommandEquip,
+	CommandUnequip,
+	CommandUse,
+	CommandFire,
+	CommandDescend,
+	CommandKeyBindings,
+}
+
+// commandDirections are the steps taken by the movement commands.
+var commandDirections = map[Command]Position{
+	CommandMoveNorth:     {X: 0, Y: -1},
+	CommandMoveSouth:     {X: 0, Y: 1},
+	CommandMoveWest:      {X: -1, Y: 0},
+	CommandMoveEast:      {X: 1, Y: 0},
+	CommandMoveNorthWest: {X: -1, Y: -1},
+	CommandMoveNorthEast: {X: 1, Y: -1},
+	CommandMoveSouthWest: {X: -1, Y: 1},
+	CommandMoveSouthEast: {X: 1, Y: 1},
+}
+
+// KeyBinding is a physical key together with the modifiers that must be held with it.
+type KeyBinding struct {
+	Key   ebiten.Key
+	Shift bool
+	Ctrl  bool
+	Alt   bool
+}
+
+// String returns the binding as it is written in a bindings file, such as "Shift+K".
+func (kb KeyBinding) String() string {
+	s := ""
+	if kb.Ctrl {
+		s += "Ctrl+"
+	}
+	if kb.Alt {
+		s += "Alt+"
+	}
+	if kb.Shift {
+		s += "Shift+"
+	}
+	return s + kb.Key.String()
+}
+
+// ParseKeyBinding reads a binding written as by String. Key names are those of
+// ebiten.Key and are not case sensitive.
+func ParseKeyBinding(s string) (KeyBinding, error) {
+	kb := KeyBinding{}
+	parts := strings.Split(strings.TrimSpace(s), "+")
+	for _, mod := range parts[:len(parts)-1] {
+		switch strings.ToLower(strings.TrimSpace(mod)) {
+		case "shift":
+			kb.Shift = true
+		case "ctrl", "control":
+			kb.Ctrl = true
+		case "alt":
+			kb.Alt = true
+		default:
+			return kb, fmt.Errorf("unknown modifier %q in %q", mod, s)
+		}
+	}
+	if err := kb.Key.UnmarshalText([]byte(strings.TrimSpace(parts[len(parts)-1]))); err != nil {
+		return kb, err
+	}
+	return kb, nil
+}
+
+// modifiersHeld reports whether exactly the binding's modifiers are held down.
+func (kb KeyBinding) modifiersHeld() bool {
+	return ebiten.IsKeyPressed(ebiten.KeyShift) == kb.Shift &&
+		ebiten.IsKeyPressed(ebiten.KeyControl) == kb.Ctrl &&
+		ebiten.IsKeyPressed(ebiten.KeyAlt) == kb.Alt
+}
+
+// KeyBindings maps every command to the keys which trigger it.
+type KeyBindings map[Command][]KeyBinding
+
+func keys(names ...string) []KeyBinding {
+	res := make([]KeyBinding, 0, len(names))
+	for _, n := range names {
+		kb, err := ParseKeyBinding(n)
+		if err != nil {
+			panic(err)
+		}
+		res = append(res, kb)
+	}
+	return res
+}
+
+// DefaultKeyBindings returns the arrow keys, the numeric keypad and the vi-keys.
+func DefaultKeyBindings() KeyBindings {
+	return KeyBindings{
+		CommandMoveNorth:     keys("ArrowUp", "Numpad8", "K"),
+		CommandMoveSouth:     keys("ArrowDown", "Numpad2", "J"),
+		CommandMoveWest:      keys("ArrowLeft", "Numpad4", "H"),
+		CommandMoveEast:      keys("ArrowRight", "Numpad6", "L"),
+		CommandMoveNorthWest: keys("Numpad7", "Y"),
+		CommandMoveNorthEast: keys("Numpad9", "U"),
+		CommandMoveSouthWest: keys("Numpad1", "B"),
+		CommandMoveSouthEast: keys("Numpad3", "N"),
+		CommandWait:          keys("Q", "Numpad5", "Period"),
+		CommandWaitTurns:     keys("Shift+Q"),
+		CommandRest:          keys("R"),
+		CommandExplore:       keys("X"),
+		CommandPickUp:        keys("G", "Comma"),
+		CommandDrop:          keys("D"),
+		CommandInventory:     keys("I"),
+		CommandEquip:         keys("E"),
+		CommandUnequip:       keys("T"),
+		CommandUse:           keys("A"),
+		CommandFire:          keys("F"),
+		CommandDescend:       keys("Shift+Period"),
+		CommandKeyBindings:   keys("F1"),
+	}
+}
+
+// DefaultBindingsPath is where the bindings file lives unless told otherwise.
+func DefaultBindingsPath() string {
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return "bindings.cfg"
+	}
+	return filepath.Join(dir, "rogolike", "bindings.cfg")
+}
+
+// LoadKeyBindings reads a bindings file on top of the defaults. Each line
+// binds a command to a comma separated list of keys, replacing its default
+// keys, for example:
+//
+//	move_north = ArrowUp, Numpad8, K
+//	wait = Shift+Period
+//
+// Blank lines and lines starting with # are ignored. A missing file is not an
+// error; the defaults are returned.
+func LoadKeyBindings(path string) (KeyBindings, error) {
+	kb := DefaultKeyBindings()
+
+	f, err := os.Open(path)
+	if os.IsNotExist(err) {
+		return kb, nil
+	}
+	if err != nil {
+		return kb, err
+	}
+	defer f.Close()
+
+	known := make(map[Command]bool)
+	for _, c := range Commands {
+		known[c] = true
+	}
+
+	scanner := bufio.NewScanner(f)
+	lineNo := 0
+	for scanner.Scan() {
+		lineNo++
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		parts := strings.SplitN(line, "=", 2)
+		if len(parts) != 2 {
+			return kb, fmt.Errorf("%s:%d: expected command = keys", path, lineNo)
+		}
+		cmd := Command(strings.TrimSpace(parts[0]))
+		if !known[cmd] {
+			return kb, fmt.Errorf("%s:%d: unknown command %q", path, lineNo, cmd)
+		}
+
+		bound := make([]KeyBinding, 0)
+		for _, k := range strings.Split(parts[1], ",") {
+			if strings.TrimSpace(k) == "" {
+				continue
+			}
+			binding, err := ParseKeyBinding(k)
+			if err != nil {
+				return kb, fmt.Errorf("%s:%d: %v", path, lineNo, err)
+			}
+			bound = append(bound, binding)
+		}
+		kb[cmd] = bound
+	}
+
+	return kb, scanner.Err()
+}
+
+// Save writes the bindings to path in the format LoadKeyBindings reads,
+// creating its directory if needed.
+func (kb KeyBindings) Save(path string) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return err
+	}
+
+	var sb strings.Builder
+	sb.WriteString("# Rogolike key bindings: command = key, Shift+key, ...\n")
+	for _, cmd := range Commands {
+		names := make([]string, 0, len(kb[cmd]))
+		for _, binding := range kb[cmd] {
+			names = append(names, binding.String())
+		}
+		fmt.Fprintf(&sb, "%s = %s\n", cmd, strings.Join(names, ", "))
+	}
+
+	return os.WriteFile(path, []byte(sb.String()), 0644)
+}
+
+// Conflicts returns every binding that triggers more than one command, with
+// the commands it triggers.
+func (kb KeyBindings) Conflicts() map[KeyBinding][]Command {
+	users := make(map[KeyBinding][]Command)
+	for _, cmd := range Commands {
+		for _, binding := range kb[cmd] {
+			users[binding] = append(users[binding], cmd)
+		}
+	}
+
+	conflicts := make(map[KeyBinding][]Command)
+	for binding, cmds := range users {
+		if len(cmds) > 1 {
+			sort.Slice(cmds, func(i, j int) bool { return cmds[i] < cmds[j] })
+			conflicts[binding] = cmds
+		}
+	}
+	return conflicts
+}
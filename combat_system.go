@@ -6,7 +6,89 @@ import (
 	"github.com/laracarvalho/rogolike/ecs"
 )
 
-func AttackSystem(g *Game, attackerPosition *Position, defenderPosition *Position) {
+// toHitDie is the die rolled to hit. Rolling its highest face is a critical
+// hit and rolling a 1 a fumble.
+const toHitDie = 10
+
+// fumbleCost is the extra time it takes to recover from a fumble.
+const fumbleCost = 50
+
+// AttackResult is how an attack went, roll by roll. A roll which beats the
+// armor class, and any cover the defender had, with the to-hit bonus added
+// hits, unless it is a fumble; a
+// critical always hits. The damage rolled is multiplied on a critical, then
+// the defense soaks up what it can of physical damage, and Resisted is what
+// the defender's resistances took off on top (negative for a vulnerability).
+// Blessings add their power to the attacker's to-hit bonus and the defender's
+// armor class.
+type AttackResult struct {
+	Attacked            bool
+	Roll                int
+	ToHitBonus          int
+	ArmorClass          int
+	Cover               int
+	Hit                 bool
+	Critical            bool
+	Fumble              bool
+	DamageType          DamageType
+	DamageRoll          int
+	Multiplier          int
+	DamageBeforeDefense int
+	Defense             int
+	Resisted            int
+	Immune              bool
+	Damage              int
+	Killed              bool
+}
+
+// AttackCost returns the time it takes to swing the weapon.
+func AttackCost(weapon *MeleeWeapon) int {
+	if weapon.Heavy {
+		return ActionCostHeavyAttack
+	}
+	return ActionCostAttack
+}
+
+// TakeDamage takes the damage off the defender's health and sees to its death,
+// and the experience it is worth, if that kills it. It reports whether the defender died.
+func TakeDamage(g *Game, defender *ecs.QueryResult, damage int) bool {
+	defenderHealth := defender.Components[health].(*Health)
+	if defenderHealth.CurrentHealth <= 0 {
+		//Already dead
+		return false
+	}
+	defenderHealth.CurrentHealth -= damage
+	if defenderHealth.CurrentHealth > 0 {
+		return false
+	}
+
+	defenderName := defender.Components[name].(*Name).Label
+	defenderMessage := defender.Components[userMessage].(*UserMessage)
+	defenderMessage.DeadMessage = fmt.Sprintf("%s has died!\n", defenderName)
+
+	//The dead don't take any more turns
+	g.Scheduler.Remove(defender.Entity.ID)
+
+	//Nobody stands on the tile any more
+	pos := defender.Components[position].(*Position)
+	level := g.Map.CurrentLevel
+	level.Tiles[level.GetIndexFromXY(pos.X, pos.Y)].Blocked = false
+	DropLoot(g, defender)
+	if xp, ok := defender.Entity.GetComponentData(xpValue); ok {
+		AwardXP(g, xp.(*XPValue).XP)
+	}
+
+	if defender.Entity.HasComponent(player) {
+		defenderMessage.GameStateMessage = "Game Over!\n"
+		g.Turn = GameOver
+	}
+	return true
+}
+
+// AttackSystem has whoever stands on attackerPosition swing their weapon at
+// whoever stands on defenderPosition, and returns how it went. The result is
+// empty, with Attacked false, when there is nobody to fight.
+func AttackSystem(g *Game, attackerPosition *Position, defenderPosition *Position) AttackResult {
 	var attacker *ecs.QueryResult = nil
 	var defender *ecs.QueryResult = nil
 
@@ -38,47 +120,72 @@ func AttackSystem(g *Game, attackerPosition *Position, defenderPosition *Positio
 	}
 	//If we somehow don't have an attacker or defender, just leave
 	if attacker == nil || defender == nil {
-		return
+		return AttackResult{}
 	}
+	return ResolveAttack(g, attacker, defender, CombatWeapon(g, attacker.Entity), 0, "swings")
+}
+
+// ResolveAttack has the attacker attack the defender with the weapon, and
+// returns how it went. Cover is added to the defender's armor class, and verb
+// is how the attack is told, such as "swings" or "shoots".
+func ResolveAttack(g *Game, attacker *ecs.QueryResult, defender *ecs.QueryResult, weapon *MeleeWeapon, cover int, verb string) AttackResult {
 	//Grab the required information
-	defenderArmor := defender.Components[armor].(*Armor)
-	defenderHealth := defender.Components[health].(*Health)
+	defenderArmor := CombatArmor(g, defender.Entity)
 	defenderName := defender.Components[name].(*Name).Label
 
-	attackerWeapon := attacker.Components[meleeWeapon].(*MeleeWeapon)
 	attackerName := attacker.Components[name].(*Name).Label
-
-	defenderMessage := defender.Components[userMessage].(*UserMessage)
 	attackerMessage := attacker.Components[userMessage].(*UserMessage)
 
 	if attacker.Components[health].(*Health).CurrentHealth <= 0 {
-		return
+		return AttackResult{}
 	}
-	//Roll a d10 to hit
-	toHitRoll := GetDiceRoll(10)
-
-	if toHitRoll+attackerWeapon.ToHitBonus > defenderArmor.ArmorClass {
-		//It's a hit!
-		damageRoll := GetRandomBetween(attackerWeapon.MinimumDamage, attackerWeapon.MaximumDamage)
 
-		damageDone := damageRoll - defenderArmor.Defense
-		//Let's not have the weapon heal the defender
-		if damageDone < 0 {
-			damageDone = 0
-		}
-		defenderHealth.CurrentHealth -= damageDone
-		attackerMessage.AttackMessage = fmt.Sprintf("%s swings %s at %s and hits for %d health.\n", attackerName, attackerWeapon.Name, defenderName, damageDone)
-
-		if defenderHealth.CurrentHealth <= 0 {
-			defenderMessage.DeadMessage = fmt.Sprintf("%s has died!\n", defenderName)
-
-			if defenderName == "Player" {
-				defenderMessage.GameStateMessage = "Game Over!\n"
-				g.Turn = GameOver
-			}
-		}
+	result := AttackResult{
+		Attacked:   true,
+		Roll:       GetDiceRoll(toHitDie),
+		ToHitBonus: weapon.ToHitBonus + statusPower(attacker.Entity, Blessed),
+		ArmorClass: defenderArmor.ArmorClass + statusPower(defender.Entity, Blessed),
+		Cover:      cover,
+		DamageType: weapon.DamageType,
+	}
+	result.Fumble = result.Roll == 1
+	result.Critical = result.Roll > toHitDie-weapon.critRange()
+	result.Hit = !result.Fumble && (result.Critical || result.Roll+result.ToHitBonus > result.ArmorClass+result.Cover)
+
+	attack := fmt.Sprintf("%s %s %s at %s", attackerName, verb, weapon.Name, defenderName)
+	switch {
+	case result.Fumble:
+		attackerMessage.AttackMessage = fmt.Sprintf("%s and fumbles!\n", attack)
+		return result
+	case !result.Hit && result.Roll+result.ToHitBonus > result.ArmorClass:
+		attackerMessage.AttackMessage = fmt.Sprintf("%s, but it is in cover.\n", attack)
+		return result
+	case !result.Hit:
+		attackerMessage.AttackMessage = fmt.Sprintf("%s and misses.\n", attack)
+		return result
+	}
 
-	} else {
-		attackerMessage.AttackMessage = fmt.Sprintf("%s swings %s at %s and misses.\n", attackerName, attackerWeapon.Name, defenderName)
+	result.DamageRoll = GetRandomBetween(weapon.MinimumDamage, weapon.MaximumDamage)
+	result.Multiplier = 1
+	if result.Critical {
+		result.Multiplier = weapon.critMultiplier()
+	}
+	result.DamageBeforeDefense = result.DamageRoll * result.Multiplier
+
+	resolved, killed := DealDamage(g, defender, Damage{Amount: result.DamageBeforeDefense, Type: result.DamageType})
+	result.Defense = resolved.Soaked
+	result.Resisted = resolved.Resisted
+	result.Immune = resolved.Immune
+	result.Damage = resolved.Taken
+	result.Killed = killed
+
+	switch {
+	case result.Immune:
+		attackerMessage.AttackMessage = fmt.Sprintf("%s, but %s damage does nothing to it.\n", attack, result.DamageType)
+	case result.Critical:
+		attackerMessage.AttackMessage = fmt.Sprintf("%s and lands a critical hit for %d health!\n", attack, result.Damage)
+	default:
+		attackerMessage.AttackMessage = fmt.Sprintf("%s and hits for %d health.\n", attack, result.Damage)
 	}
+	return result
 }
@@ -1,9 +1,11 @@
 package main
 
 import (
+	"image/color"
 	"math"
 
 	"github.com/hajimehoshi/ebiten/v2"
+	"github.com/laracarvalho/rogolike/ecs"
 )
 
 type Player struct{}
@@ -30,11 +32,80 @@ type Health struct {
 	CurrentHealth int
 }
 
+// MeleeWeapon is what an entity fights with up close. Heavy weapons take
+// longer to swing. CritRange is how many of the highest to-hit rolls are
+// critical hits and CritMultiplier what they multiply the damage by; left at
+// 0 they are 1 and 2. DamageType is the kind of damage it does.
 type MeleeWeapon struct {
-	Name          string
-	MinimumDamage int
-	MaximumDamage int
-	ToHitBonus    int
+	Name           string
+	MinimumDamage  int
+	MaximumDamage  int
+	ToHitBonus     int
+	Heavy          bool
+	CritRange      int
+	CritMultiplier int
+	DamageType     DamageType
+}
+
+func (w *MeleeWeapon) critRange() int {
+	return Max(1, w.CritRange)
+}
+
+func (w *MeleeWeapon) critMultiplier() int {
+	if w.CritMultiplier == 0 {
+		return 2
+	}
+	return w.CritMultiplier
+}
+
+// RangedWeapon is what an entity shoots or throws at enemies up to Range
+// tiles away, hitting as its MeleeWeapon would. Each shot uses up one of the
+// Ammo the entity carries, if it needs any; Thrown weapons fly themselves and
+// land where they were aimed.
+type RangedWeapon struct {
+	MeleeWeapon
+	Range  int
+	Ammo   string
+	Thrown bool
+}
+
+// Speed is how quickly an entity acts. NormalSpeed is one action per game
+// turn; twice that is two actions per turn.
+type Speed struct {
+	Speed int
+}
+
+// Regeneration heals an entity by one point of health every Turns game turns.
+// LastTurn is the game turn it last healed on.
+type Regeneration struct {
+	Turns    int
+	LastTurn int
+}
+
+// Experience is how far an entity has come: its Level, all the XP it has
+// earned, and how many level ups it has yet to pick a boost for. The boosts
+// picked so far add to its to-hit bonus, damage and armor class.
+type Experience struct {
+	Level      int
+	XP         int
+	Pending    int
+	ToHitBonus int
+	Damage     int
+	ArmorClass int
+}
+
+// XPValue is the experience a monster is worth to the player when it dies.
+type XPValue struct {
+	XP int
+}
+
+// Status is a status effect an entity is under. It lasts Turns more game
+// turns, Power is how strong it is, and LastTurn is the game turn it last
+// ticked on.
+type Status struct {
+	Turns    int
+	Power    int
+	LastTurn int
 }
 
 type Armor struct {
@@ -43,10 +114,43 @@ type Armor struct {
 	ArmorClass int
 }
 
+// LightSource makes an entity shed light around itself. Flicker is how much
+// its strength wavers, from 0 (steady) to 1.
+type LightSource struct {
+	Radius  int
+	Color   color.RGBA
+	Flicker float64
+}
+
+// Item marks an entity which can be picked up and carried.
+type Item struct{}
+
+// Stackable items of the same name share one inventory slot. Count is how many
+// there are in the stack.
+type Stackable struct {
+	Count int
+}
+
+// Weight is how heavy one of an item is.
+type Weight struct {
+	Weight int
+}
+
+// Inventory holds the items an entity carries. MaxItems is how many slots it
+// has and MaxWeight how much it can carry altogether.
+type Inventory struct {
+	Items     []ecs.EntityID
+	MaxItems  int
+	MaxWeight int
+}
+
+// UserMessage holds what an entity has to tell the player this turn.
+// Messages holds anything that doesn't fit the other kinds.
 type UserMessage struct {
 	AttackMessage    string
 	DeadMessage      string
 	GameStateMessage string
+	Messages         []string
 }
 
 func (p *Position) GetManhattanDistance(other *Position) int {
@@ -55,6 +159,21 @@ func (p *Position) GetManhattanDistance(other *Position) int {
 	return int(xDist) + int(yDist)
 }
 
+// GetChebyshevDistance returns the number of 8-way steps between two positions.
+func (p *Position) GetChebyshevDistance(other *Position) int {
+	xDist := math.Abs(float64(p.X - other.X))
+	yDist := math.Abs(float64(p.Y - other.Y))
+	return int(math.Max(xDist, yDist))
+}
+
+// GetOctileDistance returns the cost of the shortest 8-way path between two
+// positions when straight steps cost straight and diagonal steps cost diagonal.
+func (p *Position) GetOctileDistance(other *Position, straight int, diagonal int) int {
+	xDist := int(math.Abs(float64(p.X - other.X)))
+	yDist := int(math.Abs(float64(p.Y - other.Y)))
+	return straight*Max(xDist, yDist) + (diagonal-straight)*Min(xDist, yDist)
+}
+
 func (p *Position) IsEqual(other *Position) bool {
 	return (p.X == other.X && p.Y == other.Y)
 }
@@ -0,0 +1,150 @@
+package main
+
+import (
+	"github.com/laracarvalho/rogolike/ecs"
+)
+
+// DamageType is the kind of harm done by an attack or effect.
+type DamageType int
+
+const (
+	Slashing DamageType = iota
+	Piercing
+	Blunt
+	Fire
+	Cold
+	Lightning
+	Poison
+	Necrotic
+)
+
+func (t DamageType) String() string {
+	switch t {
+	case Slashing:
+		return "slashing"
+	case Piercing:
+		return "piercing"
+	case Blunt:
+		return "blunt"
+	case Fire:
+		return "fire"
+	case Cold:
+		return "cold"
+	case Lightning:
+		return "lightning"
+	case Poison:
+		return "poison"
+	case Necrotic:
+		return "necrotic"
+	}
+	return "unknown"
+}
+
+// IsPhysical reports whether armour protects against the damage type.
+func (t DamageType) IsPhysical() bool {
+	return t == Slashing || t == Piercing || t == Blunt
+}
+
+// Resistance is how an entity stands up to one type of damage. Percent of
+// the damage is taken off, then Flat more; a negative Percent is a
+// vulnerability, adding to the damage instead. Immune entities take none.
+type Resistance struct {
+	Percent int
+	Flat    int
+	Immune  bool
+}
+
+// Resistances are an entity's resistances by damage type. Types not listed
+// are taken in full. Statuses are the status effects it is immune to.
+type Resistances struct {
+	Types    map[DamageType]Resistance
+	Statuses map[StatusKind]bool
+}
+
+// Clone returns a copy of the resistances which shares nothing with them, so
+// that an entity can be given its own from a template.
+func (r Resistances) Clone() *Resistances {
+	c := &Resistances{
+		Types:    make(map[DamageType]Resistance, len(r.Types)),
+		Statuses: make(map[StatusKind]bool, len(r.Statuses)),
+	}
+	for t, resist := range r.Types {
+		c.Types[t] = resist
+	}
+	for kind, immune := range r.Statuses {
+		c.Statuses[kind] = immune
+	}
+	return c
+}
+
+// skeletonResistances are how skeletons stand up to damage. Their bones shatter
+// under blows but blades slip between them, and there is no flesh left to
+// poison, life to drain or wits to stun.
+var skeletonResistances = Resistances{
+	Types: map[DamageType]Resistance{
+		Blunt:    {Percent: -50},
+		Piercing: {Percent: 50},
+		Cold:     {Percent: 25},
+		Poison:   {Immune: true},
+		Necrotic: {Immune: true},
+	},
+	Statuses: map[StatusKind]bool{
+		Poisoned: true,
+		Stunned:  true,
+	},
+}
+
+// Damage is an amount of damage of one type on its way to a defender.
+type Damage struct {
+	Amount int
+	Type   DamageType
+}
+
+// DamageResolution is what became of some damage: how much armour soaked up,
+// how much resistances took off (negative when the defender was vulnerable),
+// and how much was taken in the end.
+type DamageResolution struct {
+	Damage   Damage
+	Soaked   int
+	Resisted int
+	Immune   bool
+	Taken    int
+}
+
+// ResolveDamage works out how much of the damage the defender takes. Armour's
+// defense soaks up physical damage first, then resistances reduce what is
+// left, or add to it for a vulnerability. Damage never heals.
+func ResolveDamage(g *Game, defender *ecs.Entity, d Damage) DamageResolution {
+	res := DamageResolution{Damage: d}
+	amount := d.Amount
+
+	if d.Type.IsPhysical() {
+		res.Soaked = Min(amount, Max(0, CombatArmor(g, defender).Defense))
+		amount -= res.Soaked
+	}
+
+	if r, ok := defender.GetComponentData(resistances); ok {
+		resist, listed := r.(*Resistances).Types[d.Type]
+		switch {
+		case listed && resist.Immune:
+			res.Immune = true
+			res.Resisted = amount
+			amount = 0
+		case listed:
+			after := Max(0, amount-amount*resist.Percent/100-resist.Flat)
+			res.Resisted = amount - after
+			amount = after
+		}
+	}
+
+	res.Taken = amount
+	return res
+}
+
+// DealDamage resolves the damage against the defender and takes what gets
+// through off its health.
+func DealDamage(g *Game, defender *ecs.QueryResult, d Damage) (DamageResolution, bool) {
+	res := ResolveDamage(g, defender.Entity, d)
+	killed := TakeDamage(g, defender, res.Taken)
+	return res, killed
+}
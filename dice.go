@@ -1,26 +1,34 @@
 package main
 
 import (
-	"crypto/rand"
-	"math/big"
+	"math/rand"
+	"time"
 )
 
+// rng is the game's random number generator. Every roll of the game's rules
+// comes from it, so the same seed plays out the same dungeon. Nothing which
+// goes by frames rather than turns, such as flickering lights, may use it.
+var rng = rand.New(rand.NewSource(time.Now().UnixNano()))
+
+// SeedRandom restarts the game's random number generator from the seed.
+func SeedRandom(seed int64) {
+	rng = rand.New(rand.NewSource(seed))
+}
+
 // GetRandomBetween returns a number between the two numbers inclusive.
 func GetRandomBetween(low int, high int) int {
-	return GetDiceRoll(high-low) + high
+	return GetRandomInt(high-low+1) + low
 }
 
 // GetRandomInt returns an integer from 0 to the number - 1
 func GetRandomInt(num int) int {
-	x, _ := rand.Int(rand.Reader, big.NewInt(int64(num)))
-	return int(x.Int64())
+	return rng.Intn(num)
 
 }
 
 // GetDiceRoll returns an integer from 1 to the number
 func GetDiceRoll(num int) int {
-	x, _ := rand.Int(rand.Reader, big.NewInt(int64(num)))
-	return int(x.Int64()) + 1
+	return rng.Intn(num) + 1
 
 }
 
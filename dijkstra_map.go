@@ -0,0 +1,173 @@
+package main
+
+import (
+	"container/heap"
+	"math"
+)
+
+// unreachable is the value of a tile no source can reach.
+const unreachable = math.MaxInt32
+
+// fleeCoefficient scales a map before it is inverted into a flee map. Values a
+// little above 1 make fleeing actors prefer long escape routes over corners.
+const fleeCoefficient = 1.2
+
+// DijkstraMap is a distance field over a level. Each tile holds the cost of
+// the cheapest route from it to the nearest source, so an actor reaches a
+// source by repeatedly stepping to its lowest neighbour.
+type DijkstraMap struct {
+	Values []int
+	Rules  MovementRules
+	Cost   TileCost
+	open   openSet
+	width  int
+}
+
+// NewDijkstraMap creates a map which walks with the given rules and tile costs.
+// A nil cost means WalkableCost.
+func NewDijkstraMap(rules MovementRules, cost TileCost) *DijkstraMap {
+	if cost == nil {
+		cost = WalkableCost
+	}
+	return &DijkstraMap{Rules: rules, Cost: cost}
+}
+
+// Compute fills the map with the distance from every tile to the nearest of
+// sources. The value slice is reused between calls.
+func (dm *DijkstraMap) Compute(level Level, sources []Position) {
+	dm.reset(level)
+	for _, src := range sources {
+		if !level.InBounds(src.X, src.Y) {
+			continue
+		}
+		idx := level.GetIndexFromXY(src.X, src.Y)
+		dm.Values[idx] = 0
+		heap.Push(&dm.open, node{index: idx, g: 0, f: 0})
+	}
+	dm.relax(level)
+}
+
+// ComputeFlee turns towards, a map leading to the things to run from, into a
+// map leading away from them. Distances are negated and scaled, then the map
+// is rescanned so actors prefer escape routes that keep going over dead ends.
+func (dm *DijkstraMap) ComputeFlee(level Level, towards *DijkstraMap) {
+	dm.reset(level)
+	for idx, v := range towards.Values {
+		if v == unreachable {
+			continue
+		}
+		dm.Values[idx] = int(-fleeCoefficient * float64(v))
+		heap.Push(&dm.open, node{index: idx, g: dm.Values[idx], f: dm.Values[idx]})
+	}
+	dm.relax(level)
+}
+
+// Get returns the value of the tile at x, y.
+func (dm *DijkstraMap) Get(x int, y int) int {
+	if x < 0 || x >= dm.width {
+		return unreachable
+	}
+	idx := (y * dm.width) + x
+	if idx < 0 || idx >= len(dm.Values) {
+		return unreachable
+	}
+	return dm.Values[idx]
+}
+
+// RollDownhill returns the neighbour of pos with the lowest value, provided it
+// is lower than the value of pos itself. canEnter, if not nil, can veto
+// neighbours such as tiles taken by another actor. The second return value is
+// false if there is nowhere better to go.
+func (dm *DijkstraMap) RollDownhill(level Level, pos *Position, canEnter func(x int, y int) bool) (Position, bool) {
+	best := Position{}
+	bestValue := dm.Get(pos.X, pos.Y)
+	found := false
+
+	for _, dir := range dm.Rules.Directions() {
+		if !dm.Rules.CanStep(level, pos.X, pos.Y, dir.X, dir.Y) {
+			continue
+		}
+		nx := pos.X + dir.X
+		ny := pos.Y + dir.Y
+		if canEnter != nil && !canEnter(nx, ny) {
+			continue
+		}
+		v := dm.Get(nx, ny)
+		if v < bestValue {
+			best = Position{X: nx, Y: ny}
+			bestValue = v
+			found = true
+		}
+	}
+
+	return best, found
+}
+
+func (dm *DijkstraMap) reset(level Level) {
+	dm.width = level.Width
+	if len(dm.Values) != len(level.Tiles) {
+		dm.Values = make([]int, len(level.Tiles))
+	}
+	for i := range dm.Values {
+		dm.Values[i] = unreachable
+	}
+	dm.open = dm.open[:0]
+}
+
+// relax runs Dijkstra's algorithm from whatever is queued in the open set.
+func (dm *DijkstraMap) relax(level Level) {
+	for dm.open.Len() > 0 {
+		current := heap.Pop(&dm.open).(node)
+		if current.g > dm.Values[current.index] {
+			continue
+		}
+
+		x := current.index % level.Width
+		y := current.index / level.Width
+
+		for _, dir := range dm.Rules.Directions() {
+			if !dm.Rules.CanStep(level, x, y, dir.X, dir.Y) {
+				continue
+			}
+			nx := x + dir.X
+			ny := y + dir.Y
+			cost := dm.Cost(level, nx, ny)
+			if cost < 0 {
+				continue
+			}
+
+			next := level.GetIndexFromXY(nx, ny)
+			g := current.g + cost*dm.Rules.StepCost(dir.X, dir.Y)/straightStepCost
+			if g < dm.Values[next] {
+				dm.Values[next] = g
+				heap.Push(&dm.open, node{index: next, g: g, f: g})
+			}
+		}
+	}
+}
+
+// MonsterMaps holds the distance fields all monsters share for a turn:
+// toward the player, away from the player for fleeing, and toward the items
+// lying around the level.
+type MonsterMaps struct {
+	ToPlayer   *DijkstraMap
+	FromPlayer *DijkstraMap
+	ToItems    *DijkstraMap
+}
+
+// NewMonsterMaps creates the shared maps for the given movement rules and tile costs.
+func NewMonsterMaps(rules MovementRules, cost TileCost) *MonsterMaps {
+	return &MonsterMaps{
+		ToPlayer:   NewDijkstraMap(rules, cost),
+		FromPlayer: NewDijkstraMap(rules, cost),
+		ToItems:    NewDijkstraMap(rules, cost),
+	}
+}
+
+// Update recomputes every map for the player's current position and the
+// positions of the items on the level.
+func (mm *MonsterMaps) Update(level Level, playerPosition Position, items []Position) {
+	mm.ToPlayer.Compute(level, []Position{playerPosition})
+	mm.FromPlayer.ComputeFlee(level, mm.ToPlayer)
+	mm.ToItems.Compute(level, items)
+}
@@ -0,0 +1,209 @@
+package main
+
+import (
+	"fmt"
+
+	"github.com/laracarvalho/rogolike/ecs"
+	"github.com/laracarvalho/rogolike/fov"
+)
+
+// ActionCostUse is the time it takes to drink a potion or read a scroll.
+const ActionCostUse = 100
+
+// firePenalty is the extra cost, in straight steps, of a path through
+// burning floor.
+const firePenalty = 10 * straightStepCost
+
+// TargetKind is what a consumable has to be aimed at.
+type TargetKind int
+
+const (
+	TargetNone TargetKind = iota
+	TargetTile
+	TargetDirection
+	TargetItem
+)
+
+// Target is what an item was aimed at: a tile or, for direction targeting,
+// the tile next to the user in that direction; or an item the user carries.
+type Target struct {
+	Tile Position
+	Item ecs.EntityID
+}
+
+// Effect is one thing that happens when an item is used.
+type Effect interface {
+	Apply(g *Game, user *ecs.QueryResult, target Target)
+}
+
+// Consumable is an item used up when it is used. Its effects happen in order.
+// Radius is how far around the target the effects reach, for the targeting prompt.
+type Consumable struct {
+	Effects   []Effect
+	Targeting TargetKind
+	Radius    int
+}
+
+// HealEffect gives the user back Amount health.
+type HealEffect struct {
+	Amount int
+}
+
+func (e HealEffect) Apply(g *Game, user *ecs.QueryResult, target Target) {
+	hp := user.Components[health].(*Health)
+	hp.CurrentHealth = Min(hp.MaxHealth, hp.CurrentHealth+e.Amount)
+	LogMessage(g, "You feel better.")
+}
+
+// TeleportEffect moves the user to a random empty floor tile of the level.
+type TeleportEffect struct{}
+
+func (e TeleportEffect) Apply(g *Game, user *ecs.QueryResult, target Target) {
+	level := g.Map.CurrentLevel
+	pos := user.Components[position].(*Position)
+
+	for tries := 0; tries < 1000; tries++ {
+		room := level.Rooms[GetRandomInt(len(level.Rooms))]
+		x := room.X + GetDiceRoll(room.Width-room.X-1)
+		y := room.Y + GetDiceRoll(room.Height-room.Y-1)
+		tile := level.Tiles[level.GetIndexFromXY(x, y)]
+		if tile.Blocked || ActorAt(g, x, y) != nil {
+			continue
+		}
+
+		level.Tiles[level.GetIndexFromXY(pos.X, pos.Y)].Blocked = false
+		pos.X = x
+		pos.Y = y
+		tile.Blocked = true
+		if user.Entity.HasComponent(player) {
+			level.PlayerVisible.Compute(level, pos.X, pos.Y, playerSightRadius)
+			level.RevealSeen()
+		}
+		LogMessage(g, "The world spins around you.")
+		return
+	}
+	LogMessage(g, "You feel a tug, but nothing happens.")
+}
+
+// MappingEffect reveals the whole level to the player.
+type MappingEffect struct{}
+
+func (e MappingEffect) Apply(g *Game, user *ecs.QueryResult, target Target) {
+	for _, tile := range g.Map.CurrentLevel.Tiles {
+		tile.IsRevealed = true
+		tile.Remembered = tile.Image
+	}
+	LogMessage(g, "A map of the level forms in your mind.")
+}
+
+// FireballEffect burns everybody within Radius of the target tile that the
+// blast can reach, and sets them on fire for BurnTurns turns. The floor
+// burns as long, and actors find their way around it.
+type FireballEffect struct {
+	Damage     int
+	Radius     int
+	BurnTurns  int
+	BurnDamage int
+}
+
+func (e FireballEffect) Apply(g *Game, user *ecs.QueryResult, target Target) {
+	level := g.Map.CurrentLevel
+	blast := fov.New()
+	blast.Compute(level, target.Tile.X, target.Tile.Y, e.Radius)
+
+	LogMessage(g, "A ball of fire explodes!")
+	g.DangerZones = append(g.DangerZones, DangerZone{
+		Center:  target.Tile,
+		Radius:  e.Radius,
+		Penalty: firePenalty,
+		Expires: g.Scheduler.GameTurn() + e.BurnTurns,
+	})
+	for _, tag := range []string{"players", "monsters"} {
+		for _, result := range g.World.Query(g.WorldTags[tag]) {
+			pos := result.Components[position].(*Position)
+			if !blast.IsVisible(pos.X, pos.Y) || pos.GetChebyshevDistance(&target.Tile) > e.Radius {
+				continue
+			}
+			if result.Components[health].(*Health).CurrentHealth <= 0 {
+				continue
+			}
+			resolved, _ := DealDamage(g, result, Damage{Amount: e.Damage, Type: Fire})
+			LogMessage(g, fmt.Sprintf("The %s is burned for %d health.", result.Components[name].(*Name).Label, resolved.Taken))
+			InflictStatus(g, result, Burning, e.BurnTurns, e.BurnDamage)
+		}
+	}
+}
+
+// LightningEffect strikes the first actor in the direction it is aimed, up to
+// Range tiles away, stunning it for StunTurns turns.
+type LightningEffect struct {
+	Damage    int
+	Range     int
+	StunTurns int
+}
+
+func (e LightningEffect) Apply(g *Game, user *ecs.QueryResult, target Target) {
+	level := g.Map.CurrentLevel
+	pos := user.Components[position].(*Position)
+	dx := target.Tile.X - pos.X
+	dy := target.Tile.Y - pos.Y
+
+	x, y := pos.X, pos.Y
+	for i := 0; i < e.Range; i++ {
+		x += dx
+		y += dy
+		if level.IsWall(x, y) {
+			break
+		}
+		if hit := ActorAt(g, x, y); hit != nil {
+			resolved, _ := DealDamage(g, hit, Damage{Amount: e.Damage, Type: Lightning})
+			LogMessage(g, fmt.Sprintf("Lightning strikes the %s for %d health!", hit.Components[name].(*Name).Label, resolved.Taken))
+			InflictStatus(g, hit, Stunned, e.StunTurns, 0)
+			return
+		}
+	}
+	LogMessage(g, "Lightning crackles and fizzles out.")
+}
+
+// UseItemAction has the actor use up one of a consumable item it carries,
+// aimed at Target. Using an item makes its kind known.
+type UseItemAction struct {
+	Item   ecs.EntityID
+	Target Target
+}
+
+func (a UseItemAction) Perform(g *Game, actor *ecs.QueryResult) ActionResult {
+	inv, ok := actor.Entity.GetComponentData(inventory)
+	if !ok {
+		return Failure()
+	}
+	used := g.World.GetEntityByID(a.Item)
+	if used == nil {
+		return Failure()
+	}
+	c, ok := used.Entity.GetComponentData(consumable)
+	if !ok {
+		LogMessage(g, fmt.Sprintf("You can't use the %s.", ItemName(g, used.Entity)))
+		return Failure()
+	}
+	label := ""
+	if n, ok := used.Entity.GetComponentData(name); ok {
+		label = n.(*Name).Label
+	}
+
+	//Use it up first, so the effects see the inventory as it is afterwards
+	if s, ok := used.Entity.GetComponentData(stackable); ok && s.(*Stackable).Count > 1 {
+		s.(*Stackable).Count--
+	} else {
+		inv.(*Inventory).Remove(a.Item)
+		g.World.DisposeEntity(used.Entity)
+	}
+
+	for _, effect := range c.(*Consumable).Effects {
+		effect.Apply(g, actor, a.Target)
+	}
+	if g.Identify.Learn(label) {
+		LogMessage(g, fmt.Sprintf("That was a %s.", label))
+	}
+	return Success(ActionCostUse)
+}
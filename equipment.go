@@ -0,0 +1,273 @@
+package main
+
+import (
+	"fmt"
+
+	"github.com/laracarvalho/rogolike/ecs"
+)
+
+// ActionCostEquip is the time it takes to put on or take off an item.
+const ActionCostEquip = 100
+
+// EquipSlot is where on the body an item is worn or held.
+type EquipSlot int
+
+const (
+	SlotNone EquipSlot = iota
+	SlotMainHand
+	SlotOffHand
+	SlotBody
+	SlotHead
+	SlotLeftRing
+	SlotRightRing
+	SlotRanged
+)
+
+// EquipSlots lists the slots in the order they are shown.
+var EquipSlots = []EquipSlot{SlotMainHand, SlotOffHand, SlotBody, SlotHead, SlotLeftRing, SlotRightRing, SlotRanged}
+
+func (s EquipSlot) String() string {
+	switch s {
+	case SlotMainHand:
+		return "main hand"
+	case SlotOffHand:
+		return "off hand"
+	case SlotBody:
+		return "body"
+	case SlotHead:
+		return "head"
+	case SlotLeftRing:
+		return "left hand"
+	case SlotRightRing:
+		return "right hand"
+	case SlotRanged:
+		return "back"
+	}
+	return "nowhere"
+}
+
+// fits returns the slots an item made for the given slot can go in. Rings fit either hand.
+func (s EquipSlot) fits() []EquipSlot {
+	if s == SlotLeftRing || s == SlotRightRing {
+		return []EquipSlot{SlotLeftRing, SlotRightRing}
+	}
+	return []EquipSlot{s}
+}
+
+// Equippable marks an item which can be worn or held in Slot. What it does
+// when equipped comes from its MeleeWeapon, RangedWeapon and Armor components.
+type Equippable struct {
+	Slot EquipSlot
+}
+
+// Equipment is what an entity has equipped, by slot. The items stay in its inventory.
+type Equipment struct {
+	Slots map[EquipSlot]ecs.EntityID
+}
+
+// NewEquipment creates an empty set of equipment.
+func NewEquipment() *Equipment {
+	return &Equipment{Slots: make(map[EquipSlot]ecs.EntityID)}
+}
+
+// SlotOf returns the slot the item is equipped in, or SlotNone.
+func (eq *Equipment) SlotOf(id ecs.EntityID) EquipSlot {
+	for slot, other := range eq.Slots {
+		if other == id {
+			return slot
+		}
+	}
+	return SlotNone
+}
+
+// unarmed is what an entity with equipment but nothing in its main hand fights with.
+var unarmed = MeleeWeapon{Name: "Fists", MinimumDamage: 1, MaximumDamage: 2, DamageType: Blunt}
+
+// CombatWeapon returns the weapon the entity fights with. For an entity with
+// equipment it is the weapon in its main hand, with the bonuses of everything
+// else it has equipped and of its level ups; other entities fight with their own MeleeWeapon.
+func CombatWeapon(g *Game, e *ecs.Entity) *MeleeWeapon {
+	eq, ok := e.GetComponentData(equipment)
+	if !ok {
+		if w, ok := e.GetComponentData(meleeWeapon); ok {
+			return w.(*MeleeWeapon)
+		}
+		return &unarmed
+	}
+
+	wpn := unarmed
+	equipped := eq.(*Equipment).Slots
+	if id, ok := equipped[SlotMainHand]; ok {
+		if held := g.World.GetEntityByID(id); held != nil {
+			if w, ok := held.Entity.GetComponentData(meleeWeapon); ok {
+				wpn = *w.(*MeleeWeapon)
+			}
+		}
+	}
+	for slot, id := range equipped {
+		worn := g.World.GetEntityByID(id)
+		if slot == SlotMainHand || worn == nil {
+			continue
+		}
+		if w, ok := worn.Entity.GetComponentData(meleeWeapon); ok {
+			bonus := w.(*MeleeWeapon)
+			wpn.MinimumDamage += bonus.MinimumDamage
+			wpn.MaximumDamage += bonus.MaximumDamage
+			wpn.ToHitBonus += bonus.ToHitBonus
+		}
+	}
+	if x, ok := e.GetComponentData(experience); ok {
+		xp := x.(*Experience)
+		wpn.MinimumDamage += xp.Damage
+		wpn.MaximumDamage += xp.Damage
+		wpn.ToHitBonus += xp.ToHitBonus
+	}
+	return &wpn
+}
+
+// CombatRanged returns the weapon the entity shoots with, or nil if it has
+// none, and the item it is if the entity has it equipped. For an entity with
+// equipment it is the weapon on its back, with the to-hit bonuses of its
+// rings and the like and of its level ups; other entities shoot with their own RangedWeapon.
+func CombatRanged(g *Game, e *ecs.Entity) (*RangedWeapon, *ecs.Entity) {
+	eq, ok := e.GetComponentData(equipment)
+	if !ok {
+		if w, ok := e.GetComponentData(rangedWeapon); ok {
+			return w.(*RangedWeapon), nil
+		}
+		return nil, nil
+	}
+
+	equipped := eq.(*Equipment).Slots
+	id, ok := equipped[SlotRanged]
+	if !ok {
+		return nil, nil
+	}
+	held := g.World.GetEntityByID(id)
+	if held == nil {
+		return nil, nil
+	}
+	w, ok := held.Entity.GetComponentData(rangedWeapon)
+	if !ok {
+		return nil, nil
+	}
+	wpn := *w.(*RangedWeapon)
+	for slot, id := range equipped {
+		worn := g.World.GetEntityByID(id)
+		if slot == SlotMainHand || slot == SlotRanged || worn == nil {
+			continue
+		}
+		if w, ok := worn.Entity.GetComponentData(meleeWeapon); ok {
+			wpn.ToHitBonus += w.(*MeleeWeapon).ToHitBonus
+		}
+	}
+	if x, ok := e.GetComponentData(experience); ok {
+		xp := x.(*Experience)
+		wpn.MinimumDamage += xp.Damage
+		wpn.MaximumDamage += xp.Damage
+		wpn.ToHitBonus += xp.ToHitBonus
+	}
+	return &wpn, held.Entity
+}
+
+// CombatArmor returns the protection of the entity. For an entity with
+// equipment it adds up everything it wears and its level ups, named after its
+// body armour; other entities are protected by their own Armor.
+func CombatArmor(g *Game, e *ecs.Entity) *Armor {
+	eq, ok := e.GetComponentData(equipment)
+	if !ok {
+		if a, ok := e.GetComponentData(armor); ok {
+			return a.(*Armor)
+		}
+		return &Armor{Name: "Nothing"}
+	}
+
+	total := Armor{Name: "Nothing"}
+	for slot, id := range eq.(*Equipment).Slots {
+		worn := g.World.GetEntityByID(id)
+		if worn == nil {
+			continue
+		}
+		if a, ok := worn.Entity.GetComponentData(armor); ok {
+			piece := a.(*Armor)
+			total.Defense += piece.Defense
+			total.ArmorClass += piece.ArmorClass
+			if slot == SlotBody {
+				total.Name = piece.Name
+			}
+		}
+	}
+	if x, ok := e.GetComponentData(experience); ok {
+		total.ArmorClass += x.(*Experience).ArmorClass
+	}
+	return &total
+}
+
+// EquipAction has the actor equip an item from its inventory, taking off
+// whatever was in the way.
+type EquipAction struct {
+	Item ecs.EntityID
+}
+
+func (a EquipAction) Perform(g *Game, actor *ecs.QueryResult) ActionResult {
+	eqData, ok := actor.Entity.GetComponentData(equipment)
+	if !ok {
+		return Failure()
+	}
+	eq := eqData.(*Equipment)
+	picked := g.World.GetEntityByID(a.Item)
+	if picked == nil {
+		return Failure()
+	}
+	e, ok := picked.Entity.GetComponentData(equippable)
+	if !ok {
+		LogMessage(g, fmt.Sprintf("You can't equip the %s.", ItemName(g, picked.Entity)))
+		return Failure()
+	}
+	if slot := eq.SlotOf(a.Item); slot != SlotNone {
+		LogMessage(g, fmt.Sprintf("The %s is already on your %s.", ItemName(g, picked.Entity), slot))
+		return Failure()
+	}
+
+	fits := e.(*Equippable).Slot.fits()
+	slot := fits[0]
+	for _, s := range fits {
+		if _, taken := eq.Slots[s]; !taken {
+			slot = s
+			break
+		}
+	}
+	if old, taken := eq.Slots[slot]; taken {
+		if removed := g.World.GetEntityByID(old); removed != nil {
+			LogMessage(g, fmt.Sprintf("You take off the %s.", ItemName(g, removed.Entity)))
+		}
+	}
+	eq.Slots[slot] = a.Item
+	LogMessage(g, fmt.Sprintf("You put the %s on your %s.", ItemName(g, picked.Entity), slot))
+	//Wearing it shows what it can do
+	IdentifyItem(g, picked.Entity)
+	return Success(ActionCostEquip)
+}
+
+// UnequipAction has the actor take off an item it has equipped.
+type UnequipAction struct {
+	Item ecs.EntityID
+}
+
+func (a UnequipAction) Perform(g *Game, actor *ecs.QueryResult) ActionResult {
+	eqData, ok := actor.Entity.GetComponentData(equipment)
+	if !ok {
+		return Failure()
+	}
+	eq := eqData.(*Equipment)
+	slot := eq.SlotOf(a.Item)
+	if slot == SlotNone {
+		return Failure()
+	}
+
+	delete(eq.Slots, slot)
+	if removed := g.World.GetEntityByID(a.Item); removed != nil {
+		LogMessage(g, fmt.Sprintf("You take off the %s.", ItemName(g, removed.Entity)))
+	}
+	return Success(ActionCostEquip)
+}
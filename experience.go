@@ -0,0 +1,86 @@
+package main
+
+import (
+	"fmt"
+)
+
+// levelUpHealth is how much more health every level gives.
+const levelUpHealth = 5
+
+// xpPerLevel sets the level curve: each level takes xpPerLevel times the
+// level reached more experience than the one before.
+const xpPerLevel = 25
+
+// XPForLevel returns the experience it takes altogether to reach the level.
+func XPForLevel(level int) int {
+	return xpPerLevel * level * (level - 1)
+}
+
+// MonsterXP returns the experience a monster worth base at the top of the
+// dungeon is worth at the given depth. Each level down adds half as much again.
+func MonsterXP(base int, depth int) int {
+	return base * (depth + 1) / 2
+}
+
+// LevelUpBoost is one of the choices the player gets on reaching a level.
+type LevelUpBoost struct {
+	Name        string
+	Description string
+	Apply       func(xp *Experience, hp *Health)
+}
+
+// LevelUpBoosts are the boosts offered on every level up.
+var LevelUpBoosts = []LevelUpBoost{
+	{Name: "Vitality", Description: fmt.Sprintf("%d more maximum health", levelUpHealth), Apply: func(xp *Experience, hp *Health) {
+		hp.MaxHealth += levelUpHealth
+		hp.CurrentHealth += levelUpHealth
+	}},
+	{Name: "Accuracy", Description: "+1 to hit", Apply: func(xp *Experience, hp *Health) {
+		xp.ToHitBonus++
+	}},
+	{Name: "Might", Description: "+1 damage", Apply: func(xp *Experience, hp *Health) {
+		xp.Damage++
+	}},
+	{Name: "Toughness", Description: "+1 armor class", Apply: func(xp *Experience, hp *Health) {
+		xp.ArmorClass++
+	}},
+}
+
+// AwardXP gives the player experience, levelling them up as many times as it
+// takes them past the next level on the curve. Every level raises their
+// maximum health, and asks them to pick a boost.
+func AwardXP(g *Game, amount int) {
+	for _, p := range g.World.Query(g.WorldTags["players"]) {
+		x, ok := p.Entity.GetComponentData(experience)
+		if !ok {
+			continue
+		}
+		xp := x.(*Experience)
+		hp := p.Components[health].(*Health)
+		if hp.CurrentHealth <= 0 {
+			continue
+		}
+
+		xp.XP += amount
+		for xp.XP >= XPForLevel(xp.Level+1) {
+			xp.Level++
+			xp.Pending++
+			hp.MaxHealth += levelUpHealth
+			hp.CurrentHealth += levelUpHealth
+			LogMessage(g, fmt.Sprintf("Welcome to level %d!", xp.Level))
+		}
+		if xp.Pending > 0 && g.LevelUp == nil {
+			g.LevelUp = &LevelUpScreen{}
+		}
+	}
+}
+
+// playerExperience returns the player's experience.
+func playerExperience(g *Game) *Experience {
+	for _, p := range g.World.Query(g.WorldTags["players"]) {
+		if xp, ok := p.Entity.GetComponentData(experience); ok {
+			return xp.(*Experience)
+		}
+	}
+	return &Experience{Level: 1}
+}
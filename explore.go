@@ -0,0 +1,69 @@
+package main
+
+import (
+	"github.com/laracarvalho/rogolike/ecs"
+)
+
+// ExploreActivity walks the player toward the nearest part of the level they
+// haven't seen yet, until there is none left or something interrupts them.
+type ExploreActivity struct {
+	field *DijkstraMap
+}
+
+// StartExploring sets the player off exploring, unless a monster is in view.
+func StartExploring(g *Game) {
+	if len(visibleMonsters(g)) > 0 {
+		LogMessage(g, "Not with monsters in view!")
+		return
+	}
+	StartActivity(g, &ExploreActivity{field: NewDijkstraMap(g.Movement, RevealedCost)})
+}
+
+// frontier returns the unrevealed tiles next to revealed ground: the edge of
+// what the player knows about.
+func frontier(level Level, rules MovementRules) []Position {
+	edge := make([]Position, 0)
+	for y := 0; y < level.Height; y++ {
+		for x := 0; x < level.Width; x++ {
+			tile := level.Tiles[level.GetIndexFromXY(x, y)]
+			if tile.IsRevealed {
+				continue
+			}
+			for _, dir := range rules.Directions() {
+				nx := x + dir.X
+				ny := y + dir.Y
+				if !level.InBounds(nx, ny) {
+					continue
+				}
+				neighbour := level.Tiles[level.GetIndexFromXY(nx, ny)]
+				if neighbour.IsRevealed && neighbour.TileType != WALL {
+					edge = append(edge, Position{X: x, Y: y})
+					break
+				}
+			}
+		}
+	}
+	return edge
+}
+
+func (e *ExploreActivity) NextAction(g *Game, player *ecs.QueryResult) Action {
+	level := g.Map.CurrentLevel
+	pos := player.Components[position].(*Position)
+
+	edge := frontier(level, g.Movement)
+	if len(edge) == 0 {
+		StopActivity(g, "There is nothing left to explore.")
+		return nil
+	}
+
+	e.field.Compute(level, edge)
+	next, ok := e.field.RollDownhill(level, pos, func(x int, y int) bool {
+		return ActorAt(g, x, y) == nil
+	})
+	if !ok {
+		StopActivity(g, "There is nowhere left to explore you can reach.")
+		return nil
+	}
+
+	return MoveAction{DX: next.X - pos.X, DY: next.Y - pos.Y}
+}
@@ -0,0 +1,253 @@
+/*
+Package fov implements symmetric recursive shadowcasting for computing a field of view on a 2D grid.
+
+The scan follows Albert Ford's symmetric shadowcasting: slopes are exact fractions, so if a tile A
+can see a tile B then B can also see A, and floor tiles are only lit when their centre is in view.
+Tiles may be partially opaque; light passing through them is dimmed and is stopped once it falls
+below MinTransparency.
+*/
+package fov
+
+// MinTransparency is the amount of light below which a ray is treated as blocked.
+const MinTransparency = 0.1
+
+// Map is the grid the field of view is computed over.
+// Opacity returns 0 for a clear tile, 1 for a tile which blocks sight completely
+// and anything in between for tiles which only dim it, such as smoke or foliage.
+type Map interface {
+	InBounds(x, y int) bool
+	Opacity(x, y int) float64
+}
+
+// Shape is the outline of the area within the radius.
+type Shape int
+
+const (
+	// Circle keeps tiles within the Euclidean radius.
+	Circle Shape = iota
+	// Square keeps tiles within the radius in 8-way steps.
+	Square
+	// Diamond keeps tiles within the radius in 4-way steps.
+	Diamond
+)
+
+// View stores the visibility of the tiles around an origin. Its buffer is reused
+// between calls to Compute, so a single View can be shared by many viewers that
+// are computed one after the other.
+type View struct {
+	Shape Shape
+
+	originX int
+	originY int
+	radius  int
+	size    int
+	light   []float64
+}
+
+// New returns a new field of view calculator with a circular radius.
+func New() *View {
+	return &View{Shape: Circle}
+}
+
+// Compute takes a Map along with the x and y coordinates of the viewer and
+// updates the set of tiles visible within the given radius.
+func (v *View) Compute(m Map, px, py, radius int) {
+	v.reset(px, py, radius)
+	if !m.InBounds(px, py) {
+		return
+	}
+
+	v.mark(px, py, 1)
+	for q := 0; q < 4; q++ {
+		v.scan(m, quadrant{cardinal: q, ox: px, oy: py}, 1, slope{-1, 1}, slope{1, 1}, 1)
+	}
+}
+
+// IsVisible reports whether the tile at x, y was in view at the last Compute.
+func (v *View) IsVisible(x, y int) bool {
+	return v.Visibility(x, y) > 0
+}
+
+// Visibility returns how much light from the viewer reached the tile at x, y:
+// 1 for a clear line of sight, less when it passed through partially opaque
+// tiles and 0 when the tile isn't visible at all.
+func (v *View) Visibility(x, y int) float64 {
+	idx, ok := v.index(x, y)
+	if !ok {
+		return 0
+	}
+	return v.light[idx]
+}
+
+// Origin returns the position of the viewer at the last Compute.
+func (v *View) Origin() (int, int) {
+	return v.originX, v.originY
+}
+
+func (v *View) reset(px, py, radius int) {
+	if radius < 0 {
+		radius = 0
+	}
+	v.originX = px
+	v.originY = py
+	v.radius = radius
+	v.size = 2*radius + 1
+
+	n := v.size * v.size
+	if cap(v.light) < n {
+		v.light = make([]float64, n)
+	}
+	v.light = v.light[:n]
+	for i := range v.light {
+		v.light[i] = 0
+	}
+}
+
+func (v *View) index(x, y int) (int, bool) {
+	dx := x - v.originX + v.radius
+	dy := y - v.originY + v.radius
+	if dx < 0 || dy < 0 || dx >= v.size || dy >= v.size {
+		return 0, false
+	}
+	return dy*v.size + dx, true
+}
+
+func (v *View) mark(x, y int, light float64) {
+	idx, ok := v.index(x, y)
+	if ok && light > v.light[idx] {
+		v.light[idx] = light
+	}
+}
+
+// inRadius reports whether the tile depth rows and col columns from the viewer
+// lies inside the view's shape.
+func (v *View) inRadius(depth, col int) bool {
+	if col < 0 {
+		col = -col
+	}
+	switch v.Shape {
+	case Square:
+		return depth <= v.radius && col <= v.radius
+	case Diamond:
+		return depth+col <= v.radius
+	default:
+		return depth*depth+col*col <= v.radius*v.radius
+	}
+}
+
+// scan lights one row of a quadrant between the start and end slopes and
+// recurses into the next row for every run of tiles light passes through.
+// light is the amount of light entering the row.
+func (v *View) scan(m Map, q quadrant, depth int, start, end slope, light float64) {
+	if depth > v.radius {
+		return
+	}
+
+	minCol := roundTiesUp(depth, start)
+	maxCol := roundTiesDown(depth, end)
+
+	first := true
+	prevBlocked := false
+	prevLight := 0.0
+
+	for col := minCol; col <= maxCol; col++ {
+		x, y := q.transform(depth, col)
+
+		blocked := true
+		passed := 0.0
+		if m.InBounds(x, y) {
+			passed = light * (1 - clamp(m.Opacity(x, y)))
+			blocked = passed < MinTransparency
+
+			if v.inRadius(depth, col) && (blocked || isSymmetric(depth, col, start, end)) {
+				v.mark(x, y, light)
+			}
+		}
+
+		if !first {
+			if prevBlocked && !blocked {
+				start = tileSlope(depth, col)
+			}
+			if !prevBlocked && (blocked || passed != prevLight) {
+				v.scan(m, q, depth+1, start, tileSlope(depth, col), prevLight)
+				if !blocked {
+					start = tileSlope(depth, col)
+				}
+			}
+		}
+
+		first = false
+		prevBlocked = blocked
+		prevLight = passed
+	}
+
+	if !first && !prevBlocked {
+		v.scan(m, q, depth+1, start, end, prevLight)
+	}
+}
+
+// quadrant maps row/column coordinates, relative to the viewer and facing one
+// cardinal direction, back onto the grid.
+type quadrant struct {
+	cardinal int
+	ox       int
+	oy       int
+}
+
+func (q quadrant) transform(row, col int) (int, int) {
+	switch q.cardinal {
+	case 0: //north
+		return q.ox + col, q.oy - row
+	case 1: //south
+		return q.ox + col, q.oy + row
+	case 2: //east
+		return q.ox + row, q.oy + col
+	default: //west
+		return q.ox - row, q.oy + col
+	}
+}
+
+// slope is an exact fraction num/den with a positive denominator.
+type slope struct {
+	num int
+	den int
+}
+
+// tileSlope is the slope of the near edge of the tile at depth, col.
+func tileSlope(depth, col int) slope {
+	return slope{2*col - 1, 2 * depth}
+}
+
+// isSymmetric reports whether the centre of the tile lies within the slopes,
+// which is what keeps floor visibility symmetric.
+func isSymmetric(depth, col int, start, end slope) bool {
+	return col*start.den >= depth*start.num && col*end.den <= depth*end.num
+}
+
+// roundTiesUp rounds depth*s to the nearest integer, rounding halves up.
+func roundTiesUp(depth int, s slope) int {
+	return floorDiv(2*depth*s.num+s.den, 2*s.den)
+}
+
+// roundTiesDown rounds depth*s to the nearest integer, rounding halves down.
+func roundTiesDown(depth int, s slope) int {
+	return -floorDiv(-(2*depth*s.num - s.den), 2*s.den)
+}
+
+func floorDiv(a, b int) int {
+	q := a / b
+	if (a%b != 0) && ((a < 0) != (b < 0)) {
+		q--
+	}
+	return q
+}
+
+func clamp(opacity float64) float64 {
+	if opacity < 0 {
+		return 0
+	}
+	if opacity > 1 {
+		return 1
+	}
+	return opacity
+}
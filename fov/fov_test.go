@@ -0,0 +1,225 @@
+package fov
+
+import (
+	"math/rand"
+	"strings"
+	"testing"
+)
+
+// grid is a Map drawn as text: '#' blocks sight, '~' lets half of it through,
+// and anything else is clear. '@' marks the viewer.
+type grid []string
+
+func (g grid) InBounds(x, y int) bool {
+	return y >= 0 && y < len(g) && x >= 0 && x < len(g[y])
+}
+
+func (g grid) Opacity(x, y int) float64 {
+	switch g[y][x] {
+	case '#':
+		return 1
+	case '~':
+		return 0.5
+	}
+	return 0
+}
+
+// viewer returns where the '@' is.
+func (g grid) viewer() (int, int) {
+	for y, row := range g {
+		if x := strings.IndexByte(row, '@'); x >= 0 {
+			return x, y
+		}
+	}
+	return 0, 0
+}
+
+// render draws the grid with every tile out of view replaced by '-'.
+func render(g grid, v *View) []string {
+	out := make([]string, len(g))
+	for y, row := range g {
+		b := []byte(row)
+		for x := range b {
+			if !v.IsVisible(x, y) {
+				b[x] = '-'
+			}
+		}
+		out[y] = string(b)
+	}
+	return out
+}
+
+var fixtures = []struct {
+	name   string
+	shape  Shape
+	radius int
+	grid   grid
+	want   []string
+}{
+	{
+		name:   "open room",
+		shape:  Circle,
+		radius: 3,
+		grid: grid{
+			".........",
+			".........",
+			".........",
+			".........",
+			"....@....",
+			".........",
+			".........",
+			".........",
+			".........",
+		},
+		want: []string{
+			"---------",
+			"----.----",
+			"--.....--",
+			"--.....--",
+			"-...@...-",
+			"--.....--",
+			"--.....--",
+			"----.----",
+			"---------",
+		},
+	},
+	{
+		name:   "pillar shadow",
+		shape:  Circle,
+		radius: 10,
+		grid: grid{
+			"#########",
+			"#.......#",
+			"#.......#",
+			"#..@#...#",
+			"#.......#",
+			"#.......#",
+			"#########",
+		},
+		want: []string{
+			"#########",
+			"#.......-",
+			"#.....---",
+			"#..@#----",
+			"#.....---",
+			"#.......-",
+			"#########",
+		},
+	},
+	{
+		name:   "corridor corner",
+		shape:  Circle,
+		radius: 10,
+		grid: grid{
+			"#######",
+			"#@....#",
+			"#####.#",
+			"#####.#",
+			"#####.#",
+			"#######",
+		},
+		want: []string{
+			"#######",
+			"#@....#",
+			"#####-#",
+			"-------",
+			"-------",
+			"-------",
+		},
+	},
+	{
+		name:   "circle",
+		shape:  Circle,
+		radius: 2,
+		grid:   grid{".......", ".......", ".......", "...@...", ".......", ".......", "......."},
+		want:   []string{"-------", "---.---", "--...--", "-..@..-", "--...--", "---.---", "-------"},
+	},
+	{
+		name:   "square",
+		shape:  Square,
+		radius: 2,
+		grid:   grid{".......", ".......", ".......", "...@...", ".......", ".......", "......."},
+		want:   []string{"-------", "-.....-", "-.....-", "-..@..-", "-.....-", "-.....-", "-------"},
+	},
+	{
+		name:   "diamond",
+		shape:  Diamond,
+		radius: 2,
+		grid:   grid{".......", ".......", ".......", "...@...", ".......", ".......", "......."},
+		want:   []string{"-------", "---.---", "--...--", "-..@..-", "--...--", "---.---", "-------"},
+	},
+}
+
+func TestFixtures(t *testing.T) {
+	for _, f := range fixtures {
+		v := New()
+		v.Shape = f.shape
+		x, y := f.grid.viewer()
+		v.Compute(f.grid, x, y, f.radius)
+
+		got := render(f.grid, v)
+		if strings.Join(got, "\n") != strings.Join(f.want, "\n") {
+			t.Errorf("%s: got\n%s\nwant\n%s", f.name, strings.Join(got, "\n"), strings.Join(f.want, "\n"))
+		}
+	}
+}
+
+func TestPartialOpacity(t *testing.T) {
+	cases := []struct {
+		grid grid
+		want []float64
+	}{
+		{grid{"@~...."}, []float64{1, 1, 0.5, 0.5, 0.5, 0.5}},
+		{grid{"@~~..."}, []float64{1, 1, 0.5, 0.25, 0.25, 0.25}},
+		{grid{"@~~~~."}, []float64{1, 1, 0.5, 0.25, 0.125, 0}},
+	}
+	for _, c := range cases {
+		v := New()
+		v.Compute(c.grid, 0, 0, 10)
+		for x, want := range c.want {
+			if got := v.Visibility(x, 0); got != want {
+				t.Errorf("%s: visibility at %d is %v, want %v", c.grid[0], x, got, want)
+			}
+		}
+	}
+}
+
+func TestSymmetry(t *testing.T) {
+	const size = 30
+	const radius = 8
+	r := rand.New(rand.NewSource(1))
+
+	for n := 0; n < 5; n++ {
+		g := make(grid, size)
+		for y := range g {
+			row := make([]byte, size)
+			for x := range row {
+				row[x] = '.'
+				if r.Intn(100) < 25 {
+					row[x] = '#'
+				}
+			}
+			g[y] = string(row)
+		}
+
+		views := make(map[[2]int]*View)
+		for y := 0; y < size; y++ {
+			for x := 0; x < size; x++ {
+				if g[y][x] == '.' {
+					v := New()
+					v.Compute(g, x, y, radius)
+					views[[2]int{x, y}] = v
+				}
+			}
+		}
+
+		for a, va := range views {
+			for b, vb := range views {
+				if va.IsVisible(b[0], b[1]) != vb.IsVisible(a[0], a[1]) {
+					t.Fatalf("grid %d: %v sees %v is %v, but %v sees %v is %v",
+						n, a, b, va.IsVisible(b[0], b[1]), b, a, vb.IsVisible(a[0], a[1]))
+				}
+			}
+		}
+	}
+}
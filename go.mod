@@ -5,7 +5,6 @@ go 1.20
 require (
 	github.com/hajimehoshi/ebiten v1.12.12
 	github.com/hajimehoshi/ebiten/v2 v2.6.2
-	github.com/norendren/go-fov v1.0.1
 	golang.org/x/image v0.12.0
 )
 
@@ -6,10 +6,15 @@ import (
 
 	"github.com/hajimehoshi/ebiten/v2"
 	"github.com/hajimehoshi/ebiten/v2/text"
+	"github.com/hajimehoshi/ebiten/v2/vector"
+	"github.com/laracarvalho/rogolike/ecs"
 )
 
 var hudErr error = nil
 
+var xpBarColor = color.RGBA{R: 120, G: 200, B: 255, A: 255}
+var xpBarBackground = color.RGBA{R: 40, G: 40, B: 60, A: 255}
+
 func ProcessHUD(g *Game, screen *ebiten.Image) {
 	gd := NewGameData()
 
@@ -23,18 +28,55 @@ func ProcessHUD(g *Game, screen *ebiten.Image) {
 		healthText := fmt.Sprintf("Health: %d / %d", h.CurrentHealth, h.MaxHealth)
 		text.Draw(screen, healthText, mplusNormalFont, fontX, fontY, color.White)
 		fontY += 16
-		ac := p.Components[armor].(*Armor)
+		if x, ok := p.Entity.GetComponentData(experience); ok {
+			drawExperience(screen, x.(*Experience), fontX, fontY)
+			fontY += 16
+		}
+		ac := CombatArmor(g, p.Entity)
 		acText := fmt.Sprintf("Armor Class: %d", ac.ArmorClass)
 		text.Draw(screen, acText, mplusNormalFont, fontX, fontY, color.White)
 		fontY += 16
 		defText := fmt.Sprintf("Defense: %d", ac.Defense)
 		text.Draw(screen, defText, mplusNormalFont, fontX, fontY, color.White)
 		fontY += 16
-		wpn := p.Components[meleeWeapon].(*MeleeWeapon)
+		wpn := CombatWeapon(g, p.Entity)
 		dmg := fmt.Sprintf("Damage: %d - %d", wpn.MinimumDamage, wpn.MaximumDamage)
 		text.Draw(screen, dmg, mplusNormalFont, fontX, fontY, color.White)
 		fontY += 16
 		bonus := fmt.Sprintf("To Hit Bonus: %d", wpn.ToHitBonus)
 		text.Draw(screen, bonus, mplusNormalFont, fontX, fontY, color.White)
+		fontY += 16
+		drawStatusIcons(screen, p.Entity, fontX, fontY)
+	}
+}
+
+// drawStatusIcons draws an icon for each status effect the entity is under,
+// with the turns it has left, in a row starting at x on the baseline y.
+func drawStatusIcons(screen *ebiten.Image, e *ecs.Entity, x int, y int) {
+	for _, kind := range StatusKinds {
+		s, ok := StatusOf(e, kind)
+		if !ok {
+			continue
+		}
+		info := statusInfos[kind]
+		vector.DrawFilledRect(screen, float32(x), float32(y-12), 14, 14, info.Color, false)
+		text.Draw(screen, info.Letter, mplusNormalFont, x+3, y, color.Black)
+		turns := fmt.Sprintf("%d", s.Turns)
+		text.Draw(screen, turns, mplusNormalFont, x+18, y, info.Color)
+		x += 18 + text.BoundString(mplusNormalFont, turns).Dx() + 10
 	}
 }
+
+// drawExperience shows the level the entity has reached and its XP, with a
+// bar of how far it is from the last level to the next.
+func drawExperience(screen *ebiten.Image, xp *Experience, x int, y int) {
+	last := XPForLevel(xp.Level)
+	next := XPForLevel(xp.Level + 1)
+	xpText := fmt.Sprintf("Level %d  XP: %d / %d", xp.Level, xp.XP, next)
+	text.Draw(screen, xpText, mplusNormalFont, x, y, color.White)
+
+	barX := float32(x + text.BoundString(mplusNormalFont, xpText).Dx() + 12)
+	progress := float32(xp.XP-last) / float32(next-last)
+	vector.DrawFilledRect(screen, barX, float32(y-10), 100, 8, xpBarBackground, false)
+	vector.DrawFilledRect(screen, barX, float32(y-10), 100*progress, 8, xpBarColor, false)
+}
@@ -0,0 +1,115 @@
+package main
+
+import (
+	"fmt"
+
+	"github.com/laracarvalho/rogolike/ecs"
+)
+
+// ItemClass groups the items which look alike until they are identified.
+type ItemClass int
+
+const (
+	ClassOther ItemClass = iota
+	ClassPotion
+	ClassScroll
+)
+
+var potionAppearances = []string{
+	"Murky", "Bubbling", "Fizzy", "Golden", "Smoky", "Milky", "Violet", "Oily", "Glowing", "Cloudy",
+}
+
+var scrollLabels = []string{
+	"ZELGO MER", "FOOBIE BLETCH", "XIXAXA XOXAXA", "PRATYAVAYAH", "ELBIB YLOH",
+	"VERR YED HORRE", "THARR", "JUYED AWK YACC", "NR 9", "KIRJE",
+}
+
+// Identification is what the player knows about the items of this game. Every
+// kind of potion and scroll gets a random appearance at the start of a game,
+// and is known by it until the player identifies it.
+type Identification struct {
+	appearances map[string]string
+	known       map[string]bool
+}
+
+// NewIdentification deals out the appearances for a new game, using the game's
+// random number generator.
+func NewIdentification() *Identification {
+	id := &Identification{
+		appearances: make(map[string]string),
+		known:       make(map[string]bool),
+	}
+
+	potions := rng.Perm(len(potionAppearances))
+	scrolls := rng.Perm(len(scrollLabels))
+	for _, t := range ItemTemplates {
+		switch t.Class {
+		case ClassPotion:
+			id.appearances[t.Name] = potionAppearances[potions[0]] + " Potion"
+			potions = potions[1:]
+		case ClassScroll:
+			id.appearances[t.Name] = "Scroll labelled " + scrollLabels[scrolls[0]]
+			scrolls = scrolls[1:]
+		}
+	}
+	return id
+}
+
+// IsKnown reports whether the player knows what the kind of item is.
+func (id *Identification) IsKnown(label string) bool {
+	_, disguised := id.appearances[label]
+	return !disguised || id.known[label]
+}
+
+// Learn makes the kind of item known. It reports whether it wasn't known before.
+func (id *Identification) Learn(label string) bool {
+	if id.IsKnown(label) {
+		return false
+	}
+	id.known[label] = true
+	return true
+}
+
+// Unidentified marks a piece of equipment whose affixes the player hasn't
+// found out yet. They see it as BaseName until they wear it.
+type Unidentified struct {
+	BaseName string
+}
+
+// IsIdentified reports whether the player knows everything about the item.
+func IsIdentified(g *Game, e *ecs.Entity) bool {
+	if e.HasComponent(unidentified) {
+		return false
+	}
+	if n, ok := e.GetComponentData(name); ok {
+		return g.Identify.IsKnown(n.(*Name).Label)
+	}
+	return true
+}
+
+// IdentifyItem tells the player everything about the item. It reports whether
+// there was anything they didn't know.
+func IdentifyItem(g *Game, e *ecs.Entity) bool {
+	if IsIdentified(g, e) {
+		return false
+	}
+	before := ItemName(g, e)
+	if e.HasComponent(unidentified) {
+		e.RemoveComponent(unidentified)
+	}
+	if n, ok := e.GetComponentData(name); ok {
+		g.Identify.Learn(n.(*Name).Label)
+	}
+	LogMessage(g, fmt.Sprintf("The %s is a %s.", before, ItemName(g, e)))
+	return true
+}
+
+// IdentifyEffect identifies the item it is aimed at.
+type IdentifyEffect struct{}
+
+func (e IdentifyEffect) Apply(g *Game, user *ecs.QueryResult, target Target) {
+	picked := g.World.GetEntityByID(target.Item)
+	if picked == nil || !IdentifyItem(g, picked.Entity) {
+		LogMessage(g, "You learn nothing new.")
+	}
+}
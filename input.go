@@ -0,0 +1,96 @@
+package main
+
+import (
+	"github.com/hajimehoshi/ebiten/v2/inpututil"
+)
+
+// InputSettings controls how held keys repeat, in ticks (1/60th of a second).
+// RepeatDelay is how long a key must be held before it starts repeating and
+// RepeatInterval how often it repeats after that. BufferSize is how many
+// commands are kept while the game is busy.
+type InputSettings struct {
+	RepeatDelay    int
+	RepeatInterval int
+	BufferSize     int
+}
+
+// DefaultInputSettings returns a quarter second delay, then 15 repeats a second.
+func DefaultInputSettings() InputSettings {
+	return InputSettings{
+		RepeatDelay:    15,
+		RepeatInterval: 4,
+		BufferSize:     3,
+	}
+}
+
+// InputBuffer turns key presses into commands and queues them until the player
+// can act, so keys pressed while the monsters move are not lost.
+type InputBuffer struct {
+	Settings InputSettings
+	queue    []Command
+}
+
+// NewInputBuffer creates an empty buffer with the given settings.
+func NewInputBuffer(settings InputSettings) *InputBuffer {
+	if settings.BufferSize < 1 {
+		settings.BufferSize = 1
+	}
+	if settings.RepeatInterval < 1 {
+		settings.RepeatInterval = 1
+	}
+	return &InputBuffer{Settings: settings}
+}
+
+// triggered reports whether the binding fired this tick: either its key was
+// just pressed, or it has been held long enough to repeat.
+func (ib *InputBuffer) triggered(kb KeyBinding) (pressed bool, repeated bool) {
+	if !kb.modifiersHeld() {
+		return false, false
+	}
+	if inpututil.IsKeyJustPressed(kb.Key) {
+		return true, false
+	}
+	held := inpututil.KeyPressDuration(kb.Key)
+	s := ib.Settings
+	if held > s.RepeatDelay && (held-s.RepeatDelay)%s.RepeatInterval == 0 {
+		return false, true
+	}
+	return false, false
+}
+
+// Poll reads this tick's key presses into the buffer. It is called every tick,
+// whoever's turn it is. Fresh presses are queued while there is room; repeats
+// of a held key are only queued when the buffer is empty, so letting go of a
+// key stops the player at once rather than after a backlog of steps.
+func (ib *InputBuffer) Poll(bindings KeyBindings) {
+	for _, cmd := range Commands {
+		for _, kb := range bindings[cmd] {
+			pressed, repeated := ib.triggered(kb)
+			if pressed && len(ib.queue) < ib.Settings.BufferSize {
+				ib.queue = append(ib.queue, cmd)
+			} else if repeated && len(ib.queue) == 0 {
+				ib.queue = append(ib.queue, cmd)
+			}
+		}
+	}
+}
+
+// Next takes the oldest command off the buffer.
+func (ib *InputBuffer) Next() (Command, bool) {
+	if len(ib.queue) == 0 {
+		return "", false
+	}
+	cmd := ib.queue[0]
+	ib.queue = ib.queue[1:]
+	return cmd, true
+}
+
+// Pending reports whether there is a command waiting in the buffer.
+func (ib *InputBuffer) Pending() bool {
+	return len(ib.queue) > 0
+}
+
+// Clear throws away every queued command.
+func (ib *InputBuffer) Clear() {
+	ib.queue = ib.queue[:0]
+}
@@ -0,0 +1,359 @@
+package main
+
+import (
+	"fmt"
+	"image/color"
+
+	"github.com/hajimehoshi/ebiten/v2"
+	"github.com/hajimehoshi/ebiten/v2/vector"
+	"github.com/laracarvalho/rogolike/ecs"
+)
+
+// Action costs of handling items.
+const (
+	ActionCostPickUp = 100
+	ActionCostDrop   = 100
+)
+
+// ItemTemplate describes a kind of item. Stack is the most of it found in
+// one place; items with a Stack of 0 don't stack. Equipment has a Slot, and
+// a Weapon, Ranged or Armor for what it does when equipped. Consumables have Use.
+// Only one of a Unique item is ever made in a game. Rarity is the tier of
+// equipment once its affixes have been rolled, and BaseName what it is called
+// until the player finds out about them. Potions and scrolls have a Class.
+type ItemTemplate struct {
+	Name     string
+	Weight   int
+	Stack    int
+	Color    color.RGBA
+	Slot     EquipSlot
+	Weapon   *MeleeWeapon
+	Ranged   *RangedWeapon
+	Armor    *Armor
+	Use      *Consumable
+	Unique   bool
+	Rarity   RarityTier
+	BaseName string
+	Class    ItemClass
+}
+
+var weaponColor = color.RGBA{R: 190, G: 200, B: 210, A: 255}
+var armorColor = color.RGBA{R: 140, G: 110, B: 80, A: 255}
+var ringColor = color.RGBA{R: 230, G: 180, B: 255, A: 255}
+var potionColor = color.RGBA{R: 220, G: 40, B: 60, A: 255}
+var scrollColor = color.RGBA{R: 240, G: 235, B: 210, A: 255}
+
+// starterWeapon and starterArmor are what the player sets out with.
+var starterWeapon = ItemTemplate{
+	Name: "Battle Axe", Weight: 8, Color: weaponColor, Slot: SlotMainHand,
+	Weapon: &MeleeWeapon{Name: "Battle Axe", MinimumDamage: 10, MaximumDamage: 20, ToHitBonus: 3, Heavy: true, CritMultiplier: 3},
+}
+var starterArmor = ItemTemplate{
+	Name: "Plate Armor", Weight: 25, Color: armorColor, Slot: SlotBody,
+	Armor: &Armor{Name: "Plate Armor", Defense: 15, ArmorClass: 18},
+}
+
+// ItemTemplates are all the items to be found in the dungeon. Loot tables refer to them by name.
+var ItemTemplates = []ItemTemplate{
+	{Name: "Gold Coins", Weight: 0, Stack: 25, Color: color.RGBA{R: 255, G: 215, A: 255}},
+	{Name: "Torch", Weight: 3, Color: color.RGBA{R: 200, G: 120, B: 40, A: 255}},
+	{Name: "Rope", Weight: 5, Color: color.RGBA{R: 170, G: 150, B: 110, A: 255}},
+	{Name: "Dagger", Weight: 1, Color: weaponColor, Slot: SlotMainHand,
+		Weapon: &MeleeWeapon{Name: "Dagger", MinimumDamage: 2, MaximumDamage: 5, ToHitBonus: 2, CritRange: 2, DamageType: Piercing}},
+	{Name: "Mace", Weight: 6, Color: weaponColor, Slot: SlotMainHand,
+		Weapon: &MeleeWeapon{Name: "Mace", MinimumDamage: 4, MaximumDamage: 9, ToHitBonus: 1, DamageType: Blunt}},
+	{Name: "Short Bow", Weight: 2, Color: weaponColor, Slot: SlotRanged,
+		Ranged: &RangedWeapon{MeleeWeapon: MeleeWeapon{Name: "Short Bow", MinimumDamage: 2, MaximumDamage: 6, DamageType: Piercing}, Range: 8, Ammo: "Arrows"}},
+	{Name: "Arrows", Weight: 0, Stack: 20, Color: weaponColor},
+	{Name: "Throwing Daggers", Weight: 1, Stack: 5, Color: weaponColor, Slot: SlotRanged,
+		Ranged: &RangedWeapon{MeleeWeapon: MeleeWeapon{Name: "Throwing Dagger", MinimumDamage: 1, MaximumDamage: 4, ToHitBonus: 1, CritRange: 2, DamageType: Piercing}, Range: 5, Thrown: true}},
+	{Name: "Wooden Shield", Weight: 6, Color: armorColor, Slot: SlotOffHand,
+		Armor: &Armor{Name: "Wooden Shield", Defense: 1, ArmorClass: 2}},
+	{Name: "Leather Cap", Weight: 1, Color: armorColor, Slot: SlotHead,
+		Armor: &Armor{Name: "Leather Cap", Defense: 1, ArmorClass: 1}},
+	{Name: "Ring of Accuracy", Weight: 0, Color: ringColor, Slot: SlotLeftRing,
+		Weapon: &MeleeWeapon{Name: "Ring of Accuracy", ToHitBonus: 2}},
+	{Name: "Ring of Protection", Weight: 0, Color: ringColor, Slot: SlotLeftRing,
+		Armor: &Armor{Name: "Ring of Protection", Defense: 1, ArmorClass: 1}},
+	{Name: "Potion of Healing", Weight: 1, Stack: 2, Color: potionColor, Class: ClassPotion,
+		Use: &Consumable{Effects: []Effect{HealEffect{Amount: 15}}}},
+	{Name: "Potion of Regeneration", Weight: 1, Stack: 1, Color: potionColor, Class: ClassPotion,
+		Use: &Consumable{Effects: []Effect{StatusEffect{Kind: Regenerating, Turns: 10, Power: 2}}}},
+	{Name: "Potion of Poison", Weight: 1, Stack: 1, Color: potionColor, Class: ClassPotion,
+		Use: &Consumable{Effects: []Effect{StatusEffect{Kind: Poisoned, Turns: 6, Power: 2}}}},
+	{Name: "Potion of Slowness", Weight: 1, Stack: 1, Color: potionColor, Class: ClassPotion,
+		Use: &Consumable{Effects: []Effect{StatusEffect{Kind: Slowed, Turns: 10}}}},
+	{Name: "Scroll of Teleport", Weight: 0, Stack: 1, Color: scrollColor, Class: ClassScroll,
+		Use: &Consumable{Effects: []Effect{TeleportEffect{}}}},
+	{Name: "Scroll of Mapping", Weight: 0, Stack: 1, Color: scrollColor, Class: ClassScroll,
+		Use: &Consumable{Effects: []Effect{MappingEffect{}}}},
+	{Name: "Scroll of Fireball", Weight: 0, Stack: 1, Color: scrollColor, Class: ClassScroll,
+		Use: &Consumable{Effects: []Effect{FireballEffect{Damage: 12, Radius: 2, BurnTurns: 3, BurnDamage: 2}}, Targeting: TargetTile, Radius: 2}},
+	{Name: "Scroll of Lightning", Weight: 0, Stack: 1, Color: scrollColor, Class: ClassScroll,
+		Use: &Consumable{Effects: []Effect{LightningEffect{Damage: 15, Range: 8, StunTurns: 2}}, Targeting: TargetDirection}},
+	{Name: "Scroll of Identify", Weight: 0, Stack: 2, Color: scrollColor, Class: ClassScroll,
+		Use: &Consumable{Effects: []Effect{IdentifyEffect{}}, Targeting: TargetItem}},
+	{Name: "Scroll of Blessing", Weight: 0, Stack: 1, Color: scrollColor, Class: ClassScroll,
+		Use: &Consumable{Effects: []Effect{StatusEffect{Kind: Blessed, Turns: 20, Power: 2}}}},
+	{Name: "Bone Crown", Weight: 2, Color: ringColor, Slot: SlotHead, Unique: true,
+		Armor: &Armor{Name: "Bone Crown", Defense: 3, ArmorClass: 3}},
+}
+
+// ItemTemplateNamed returns the template of the item with the given name.
+func ItemTemplateNamed(label string) (ItemTemplate, bool) {
+	for _, t := range ItemTemplates {
+		if t.Name == label {
+			return t, true
+		}
+	}
+	return ItemTemplate{}, false
+}
+
+// itemImages caches the picture of each colour of item.
+var itemImages = make(map[color.RGBA]*ebiten.Image)
+
+// itemImage returns the picture of an item: a small blob of its colour.
+func itemImage(clr color.RGBA) *ebiten.Image {
+	if img, ok := itemImages[clr]; ok {
+		return img
+	}
+	gd := NewGameData()
+	img := ebiten.NewImage(gd.TileWidth, gd.TileHeight)
+	vector.DrawFilledCircle(img, float32(gd.TileWidth)/2, float32(gd.TileHeight)/2, float32(gd.TileWidth)/4, clr, true)
+	itemImages[clr] = img
+	return img
+}
+
+// CreateItem creates an item from the template, not lying anywhere yet.
+func CreateItem(engine *ecs.Engine, t ItemTemplate) *ecs.Entity {
+	e := engine.NewEntity().
+		AddComponent(item, &Item{}).
+		AddComponent(name, &Name{Label: t.Name}).
+		AddComponent(weight, &Weight{Weight: t.Weight}).
+		AddComponent(renderable, &Renderable{Image: itemImage(t.Color)})
+	if t.Stack > 0 {
+		e.AddComponent(stackable, &Stackable{Count: GetDiceRoll(t.Stack)})
+	}
+	if t.Slot != SlotNone {
+		e.AddComponent(equippable, &Equippable{Slot: t.Slot})
+	}
+	if t.Weapon != nil {
+		wpn := *t.Weapon
+		e.AddComponent(meleeWeapon, &wpn)
+	}
+	if t.Ranged != nil {
+		wpn := *t.Ranged
+		e.AddComponent(rangedWeapon, &wpn)
+	}
+	if t.Armor != nil {
+		arm := *t.Armor
+		e.AddComponent(armor, &arm)
+	}
+	if t.Use != nil {
+		use := *t.Use
+		e.AddComponent(consumable, &use)
+	}
+	if t.Slot != SlotNone {
+		e.AddComponent(rarity, &Rarity{Tier: t.Rarity})
+	}
+	if t.BaseName != "" {
+		e.AddComponent(unidentified, &Unidentified{BaseName: t.BaseName})
+	}
+	return e
+}
+
+// SpawnItem creates an item from the template lying at x, y.
+func SpawnItem(engine *ecs.Engine, t ItemTemplate, x int, y int) *ecs.Entity {
+	return CreateItem(engine, t).AddComponent(position, &Position{X: x, Y: y})
+}
+
+// ItemName returns the name of the item as the player sees it, with the size
+// of its stack. Items they haven't identified go by how they look.
+func ItemName(g *Game, e *ecs.Entity) string {
+	label := ""
+	if n, ok := e.GetComponentData(name); ok {
+		label = n.(*Name).Label
+	}
+	if u, ok := e.GetComponentData(unidentified); ok {
+		label = u.(*Unidentified).BaseName
+	} else if !g.Identify.IsKnown(label) {
+		label = g.Identify.appearances[label]
+	}
+	if s, ok := e.GetComponentData(stackable); ok && s.(*Stackable).Count > 1 {
+		return fmt.Sprintf("%s (%d)", label, s.(*Stackable).Count)
+	}
+	return label
+}
+
+// ItemColor returns the colour the item's name is shown in. The rarity of
+// items isn't plain until they are identified.
+func ItemColor(g *Game, e *ecs.Entity) color.Color {
+	if r, ok := e.GetComponentData(rarity); ok && IsIdentified(g, e) {
+		return rarityColors[r.(*Rarity).Tier]
+	}
+	return color.White
+}
+
+// ItemWeight returns the weight of the item, the whole stack of it.
+func ItemWeight(e *ecs.Entity) int {
+	w := 0
+	if wt, ok := e.GetComponentData(weight); ok {
+		w = wt.(*Weight).Weight
+	}
+	if s, ok := e.GetComponentData(stackable); ok {
+		w *= s.(*Stackable).Count
+	}
+	return w
+}
+
+// ItemLetter returns the letter the item in the given inventory slot is picked with.
+func ItemLetter(slot int) string {
+	return string(rune('a' + slot))
+}
+
+// letterSlot returns the inventory slot picked with the key, if it is a letter.
+func letterSlot(k ebiten.Key) (int, bool) {
+	if k < ebiten.KeyA || k > ebiten.KeyZ {
+		return 0, false
+	}
+	return int(k - ebiten.KeyA), true
+}
+
+// Entities returns the items in the inventory, in slot order.
+func (inv *Inventory) Entities(g *Game) []*ecs.Entity {
+	items := make([]*ecs.Entity, 0, len(inv.Items))
+	for _, id := range inv.Items {
+		if e := g.World.GetEntityByID(id); e != nil {
+			items = append(items, e.Entity)
+		}
+	}
+	return items
+}
+
+// TotalWeight returns the weight of everything in the inventory.
+func (inv *Inventory) TotalWeight(g *Game) int {
+	total := 0
+	for _, e := range inv.Entities(g) {
+		total += ItemWeight(e)
+	}
+	return total
+}
+
+// Remove takes the item out of the inventory.
+func (inv *Inventory) Remove(id ecs.EntityID) {
+	for i, other := range inv.Items {
+		if other == id {
+			inv.Items = append(inv.Items[:i], inv.Items[i+1:]...)
+			return
+		}
+	}
+}
+
+// ItemsAt returns the items lying at x, y.
+func ItemsAt(g *Game, x int, y int) []*ecs.QueryResult {
+	items := make([]*ecs.QueryResult, 0)
+	for _, result := range g.World.Query(g.WorldTags["items"]) {
+		pos := result.Components[position].(*Position)
+		if pos.X == x && pos.Y == y {
+			items = append(items, result)
+		}
+	}
+	return items
+}
+
+// sameStack returns the item in the inventory the picked up item stacks with, or nil.
+func sameStack(g *Game, inv *Inventory, picked *ecs.Entity) *ecs.Entity {
+	if !picked.HasComponent(stackable) {
+		return nil
+	}
+	label := ""
+	if n, ok := picked.GetComponentData(name); ok {
+		label = n.(*Name).Label
+	}
+	for _, e := range inv.Entities(g) {
+		if n, ok := e.GetComponentData(name); ok && e.HasComponent(stackable) && n.(*Name).Label == label {
+			return e
+		}
+	}
+	return nil
+}
+
+// PickUpAction has the actor pick up the top item lying where it stands.
+type PickUpAction struct{}
+
+func (a PickUpAction) Perform(g *Game, actor *ecs.QueryResult) ActionResult {
+	inv, ok := actor.Entity.GetComponentData(inventory)
+	if !ok {
+		return Failure()
+	}
+	pack := inv.(*Inventory)
+	pos := actor.Components[position].(*Position)
+
+	items := ItemsAt(g, pos.X, pos.Y)
+	if len(items) == 0 {
+		LogMessage(g, "There is nothing here.")
+		return Failure()
+	}
+	picked := items[len(items)-1].Entity
+
+	if pack.TotalWeight(g)+ItemWeight(picked) > pack.MaxWeight {
+		LogMessage(g, fmt.Sprintf("The %s is too heavy to carry.", ItemName(g, picked)))
+		return Failure()
+	}
+
+	if stack := sameStack(g, pack, picked); stack != nil {
+		s, _ := stack.GetComponentData(stackable)
+		p, _ := picked.GetComponentData(stackable)
+		s.(*Stackable).Count += p.(*Stackable).Count
+		LogMessage(g, fmt.Sprintf("You pick up the %s.", ItemName(g, picked)))
+		g.World.DisposeEntity(picked)
+		return Success(ActionCostPickUp)
+	}
+
+	if len(pack.Items) >= pack.MaxItems {
+		LogMessage(g, "Your pack is full.")
+		return Failure()
+	}
+	picked.RemoveComponent(position)
+	pack.Items = append(pack.Items, picked.ID)
+	LogMessage(g, fmt.Sprintf("%s - %s", ItemLetter(len(pack.Items)-1), ItemName(g, picked)))
+	return Success(ActionCostPickUp)
+}
+
+// DropAction has the actor drop an item it carries where it stands.
+type DropAction struct {
+	Item ecs.EntityID
+}
+
+func (a DropAction) Perform(g *Game, actor *ecs.QueryResult) ActionResult {
+	inv, ok := actor.Entity.GetComponentData(inventory)
+	if !ok {
+		return Failure()
+	}
+	dropped := g.World.GetEntityByID(a.Item)
+	if dropped == nil {
+		return Failure()
+	}
+	pos := actor.Components[position].(*Position)
+
+	if eq, ok := actor.Entity.GetComponentData(equipment); ok {
+		delete(eq.(*Equipment).Slots, eq.(*Equipment).SlotOf(a.Item))
+	}
+	inv.(*Inventory).Remove(a.Item)
+	dropped.Entity.AddComponent(position, &Position{X: pos.X, Y: pos.Y})
+	LogMessage(g, fmt.Sprintf("You drop the %s.", ItemName(g, dropped.Entity)))
+	return Success(ActionCostDrop)
+}
+
+// visibleItems returns the items lying where the player can see them.
+func visibleItems(g *Game) map[ecs.EntityID]bool {
+	level := g.Map.CurrentLevel
+	seen := make(map[ecs.EntityID]bool)
+	for _, result := range g.World.Query(g.WorldTags["items"]) {
+		pos := result.Components[position].(*Position)
+		if level.PlayerCanSee(pos.X, pos.Y) {
+			seen[result.Entity.ID] = true
+		}
+	}
+	return seen
+}
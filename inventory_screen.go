@@ -0,0 +1,161 @@
+package main
+
+import (
+	"fmt"
+	"image/color"
+
+	"github.com/hajimehoshi/ebiten/v2"
+	"github.com/hajimehoshi/ebiten/v2/inpututil"
+	"github.com/hajimehoshi/ebiten/v2/text"
+	"github.com/hajimehoshi/ebiten/v2/vector"
+	"github.com/laracarvalho/rogolike/ecs"
+)
+
+// InventoryMode is what picking an item on the inventory screen does.
+type InventoryMode int
+
+const (
+	InventoryBrowse InventoryMode = iota
+	InventoryDrop
+	InventoryEquip
+	InventoryUnequip
+	InventoryUse
+	InventoryTarget
+)
+
+// inventoryTitles are shown at the top of the screen in each mode.
+var inventoryTitles = map[InventoryMode]string{
+	InventoryBrowse:  "Inventory",
+	InventoryDrop:    "Drop which item?",
+	InventoryEquip:   "Equip which item?",
+	InventoryUnequip: "Take off which item?",
+	InventoryUse:     "Use which item?",
+	InventoryTarget:  "Use it on which item?",
+}
+
+// InventoryScreen lists what the player carries, each item with the letter
+// that picks it. Escape leaves. Using is the item being aimed at another
+// item in InventoryTarget mode.
+type InventoryScreen struct {
+	Mode    InventoryMode
+	Message string
+	Using   ecs.EntityID
+}
+
+// OpenInventory shows the inventory screen in the given mode.
+func OpenInventory(g *Game, mode InventoryMode) {
+	g.Inventory = &InventoryScreen{Mode: mode}
+	g.Input.Clear()
+}
+
+// playerEquipment returns what the player has equipped.
+func playerEquipment(g *Game) *Equipment {
+	for _, p := range g.World.Query(g.WorldTags["players"]) {
+		if eq, ok := p.Entity.GetComponentData(equipment); ok {
+			return eq.(*Equipment)
+		}
+	}
+	return NewEquipment()
+}
+
+// playerInventory returns the player's inventory.
+func playerInventory(g *Game) *Inventory {
+	for _, p := range g.World.Query(g.WorldTags["players"]) {
+		if inv, ok := p.Entity.GetComponentData(inventory); ok {
+			return inv.(*Inventory)
+		}
+	}
+	return &Inventory{}
+}
+
+// UpdateInventoryScreen handles the input of the inventory screen.
+func UpdateInventoryScreen(g *Game) {
+	is := g.Inventory
+	if inpututil.IsKeyJustPressed(ebiten.KeyEscape) {
+		g.Inventory = nil
+		g.Input.Clear()
+		return
+	}
+
+	items := playerInventory(g).Entities(g)
+	for _, k := range inpututil.AppendJustPressedKeys(nil) {
+		slot, ok := letterSlot(k)
+		if !ok {
+			continue
+		}
+		if slot >= len(items) {
+			is.Message = fmt.Sprintf("You have no item %s.", ItemLetter(slot))
+			return
+		}
+
+		picked := items[slot]
+		switch is.Mode {
+		case InventoryBrowse:
+			is.Message = fmt.Sprintf("%s - %s, weight %d", ItemLetter(slot), ItemName(g, picked), ItemWeight(picked))
+		case InventoryDrop:
+			g.queuedAction = DropAction{Item: picked.ID}
+		case InventoryEquip:
+			g.queuedAction = EquipAction{Item: picked.ID}
+		case InventoryUnequip:
+			if playerEquipment(g).SlotOf(picked.ID) == SlotNone {
+				is.Message = fmt.Sprintf("You aren't wearing the %s.", ItemName(g, picked))
+				return
+			}
+			g.queuedAction = UnequipAction{Item: picked.ID}
+		case InventoryUse:
+			if !picked.HasComponent(consumable) {
+				is.Message = fmt.Sprintf("You can't use the %s.", ItemName(g, picked))
+				return
+			}
+			g.Inventory = nil
+			g.Input.Clear()
+			UseItem(g, picked.ID)
+			return
+		case InventoryTarget:
+			g.queuedAction = UseItemAction{Item: is.Using, Target: Target{Item: picked.ID}}
+		}
+		if g.queuedAction != nil {
+			g.Inventory = nil
+			g.Input.Clear()
+		}
+		return
+	}
+}
+
+// DrawInventoryScreen draws the inventory screen over the game.
+func DrawInventoryScreen(g *Game, screen *ebiten.Image) {
+	is := g.Inventory
+	w, h := screen.Bounds().Dx(), screen.Bounds().Dy()
+	vector.DrawFilledRect(screen, 0, 0, float32(w), float32(h), color.RGBA{A: 230}, false)
+
+	inv := playerInventory(g)
+	eq := playerEquipment(g)
+	title := inventoryTitles[is.Mode]
+
+	fontX := 32
+	fontY := 40
+	text.Draw(screen, title, mplusNormalFont, fontX, fontY, color.White)
+	fontY += 16
+	summary := fmt.Sprintf("%d / %d items, weight %d / %d   Escape: close", len(inv.Items), inv.MaxItems, inv.TotalWeight(g), inv.MaxWeight)
+	text.Draw(screen, summary, mplusNormalFont, fontX, fontY, color.White)
+	fontY += 32
+
+	items := inv.Entities(g)
+	if len(items) == 0 {
+		text.Draw(screen, "You aren't carrying anything.", mplusNormalFont, fontX, fontY, color.White)
+		fontY += 16
+	}
+	for i, e := range items {
+		line := fmt.Sprintf("%s - %s", ItemLetter(i), ItemName(g, e))
+		if slot := eq.SlotOf(e.ID); slot != SlotNone {
+			line += fmt.Sprintf(" (%s)", slot)
+		}
+		text.Draw(screen, line, mplusNormalFont, fontX, fontY, ItemColor(g, e))
+		fontY += 16
+	}
+
+	if is.Message != "" {
+		fontY += 16
+		text.Draw(screen, is.Message, mplusNormalFont, fontX, fontY, selectedColor)
+	}
+}
@@ -4,15 +4,30 @@ import (
 	"log"
 
 	"github.com/hajimehoshi/ebiten/v2"
+	"github.com/hajimehoshi/ebiten/v2/colorm"
 	"github.com/hajimehoshi/ebiten/v2/ebitenutil"
-	"github.com/norendren/go-fov/fov"
+	"github.com/laracarvalho/rogolike/ecs"
+	"github.com/laracarvalho/rogolike/fov"
 )
 
 // Level holds the tile information for a complete dungeon level.
+// Width and Height are its size in tiles.
 type Level struct {
+	Width         int
+	Height        int
 	Tiles         []*MapTile
 	Rooms         []Rect
 	PlayerVisible *fov.View
+	Light         *LightMap
+	Ghosts        map[ecs.EntityID]*Ghost
+	Depth         int
+}
+
+// Ghost is where the player last saw an entity which is now out of sight.
+type Ghost struct {
+	X     int
+	Y     int
+	Image *ebiten.Image
 }
 
 var levelHeight int = 0
@@ -22,9 +37,11 @@ type TileType int
 const (
 	WALL TileType = iota
 	FLOOR
+	STAIRS
 )
 
 // MapTile is a single Tile on a given level
+// Remembered is the image of the tile when the player last saw it.
 type MapTile struct {
 	PixelX     int
 	PixelY     int
@@ -32,15 +49,25 @@ type MapTile struct {
 	Image      *ebiten.Image
 	TileType   TileType
 	IsRevealed bool
+	Remembered *ebiten.Image
 }
 
-// NewLevel creates a new game level in a dungeon.
-func NewLevel() Level {
-	l := Level{}
+// rememberedColorM draws what the player remembers but can't see right now: washed out and dim.
+var rememberedColorM = func() colorm.ColorM {
+	cm := colorm.ColorM{}
+	cm.ChangeHSV(0, 0.2, 0.4)
+	return cm
+}()
+
+// NewLevel creates a new game level in a dungeon, depth levels down.
+func NewLevel(depth int) Level {
+	l := Level{Depth: depth}
 	rooms := make([]Rect, 0)
 	l.Rooms = rooms
 	l.GenerateLevelTiles()
 	l.PlayerVisible = fov.New()
+	l.Light = NewLightMap()
+	l.Ghosts = make(map[ecs.EntityID]*Ghost)
 
 	return l
 }
@@ -48,13 +75,14 @@ func NewLevel() Level {
 // GetIndexFromXY gets the index of the map array from a given X,Y TILE coordinate.
 // This coordinate is logical tiles, not pixels.
 func (level *Level) GetIndexFromXY(x int, y int) int {
-	gd := NewGameData()
-	return (y * gd.ScreenWidth) + x
+	return (y * level.Width) + x
 }
 
 func (level *Level) createTiles() []*MapTile {
 	gd := NewGameData()
-	tiles := make([]*MapTile, levelHeight*gd.ScreenWidth)
+	level.Width = gd.ScreenWidth
+	level.Height = levelHeight
+	tiles := make([]*MapTile, level.Height*level.Width)
 	index := 0
 
 	wall, _, wallErr := ebitenutil.NewImageFromFile("assets/wall.png")
@@ -62,8 +90,8 @@ func (level *Level) createTiles() []*MapTile {
 		log.Fatal(wallErr)
 	}
 
-	for x := 0; x < gd.ScreenWidth; x++ {
-		for y := 0; y < levelHeight; y++ {
+	for x := 0; x < level.Width; x++ {
+		for y := 0; y < level.Height; y++ {
 			index = level.GetIndexFromXY(x, y)
 
 			tile := MapTile{
@@ -82,30 +110,28 @@ func (level *Level) createTiles() []*MapTile {
 }
 
 func (level *Level) DrawLevel(screen *ebiten.Image) {
-	gd := NewGameData()
-
-	for x := 0; x < gd.ScreenWidth; x++ {
-		for y := 0; y < levelHeight; y++ {
+	for x := 0; x < level.Width; x++ {
+		for y := 0; y < level.Height; y++ {
 			idx := level.GetIndexFromXY(x, y)
 			tile := level.Tiles[idx]
-			isVis := level.PlayerVisible.IsVisible(x, y)
+			isVis := level.PlayerCanSee(x, y)
 
 			if isVis {
 				op := &ebiten.DrawImageOptions{}
 				op.GeoM.Translate(float64(tile.PixelX), float64(tile.PixelY))
+				r, g, b := level.Light.Tint(x, y)
+				op.ColorScale.Scale(r, g, b, 1)
 				screen.DrawImage(tile.Image, op)
 				level.Tiles[idx].IsRevealed = true
+				level.Tiles[idx].Remembered = tile.Image
 
 			} else if tile.IsRevealed == true {
-				op := &ebiten.DrawImageOptions{}
+				//Draw the tile as the player last saw it
+				op := &colorm.DrawImageOptions{}
 				op.GeoM.Translate(float64(tile.PixelX), float64(tile.PixelY))
-				//op.ColorM.Translate(100, 100, 100, 0.45)
-				screen.DrawImage(tile.Image, op)
+				colorm.DrawImage(screen, tile.Remembered, rememberedColorM, op)
 			}
-
-			op := &ebiten.DrawImageOptions{}
-			op.GeoM.Translate(float64(tile.PixelX), float64(tile.PixelY))
-			screen.DrawImage(tile.Image, op)
+			//Tiles the player has never seen stay black
 		}
 	}
 
@@ -128,8 +154,6 @@ func (level *Level) createRoom(room Rect) {
 }
 
 func (level *Level) createHorizontalTunnel(x1 int, x2 int, y int) {
-	gd := NewGameData()
-
 	floor, _, err := ebitenutil.NewImageFromFile("assets/floor.png")
 	if err != nil {
 		log.Fatal(err)
@@ -137,7 +161,7 @@ func (level *Level) createHorizontalTunnel(x1 int, x2 int, y int) {
 
 	for x := Min(x1, x2); x < Max(x1, x2)+1; x++ {
 		index := level.GetIndexFromXY(x, y)
-		if index > 0 && index < gd.ScreenWidth*levelHeight {
+		if index > 0 && index < len(level.Tiles) {
 			level.Tiles[index].Blocked = false
 			level.Tiles[index].TileType = FLOOR
 			level.Tiles[index].Image = floor
@@ -146,8 +170,6 @@ func (level *Level) createHorizontalTunnel(x1 int, x2 int, y int) {
 }
 
 func (level *Level) createVerticalTunnel(y1 int, y2 int, x int) {
-	gd := NewGameData()
-
 	floor, _, err := ebitenutil.NewImageFromFile("assets/floor.png")
 	if err != nil {
 		log.Fatal(err)
@@ -155,7 +177,7 @@ func (level *Level) createVerticalTunnel(y1 int, y2 int, x int) {
 
 	for y := Min(y1, y2); y < Max(y1, y2)+1; y++ {
 		index := level.GetIndexFromXY(x, y)
-		if index > 0 && index < gd.ScreenWidth*levelHeight {
+		if index > 0 && index < len(level.Tiles) {
 			level.Tiles[index].Blocked = false
 			level.Tiles[index].TileType = FLOOR
 			level.Tiles[index].Image = floor
@@ -214,17 +236,53 @@ func (level *Level) GenerateLevelTiles() {
 			contains_rooms = true
 		}
 	}
+
+	level.createStairs()
+}
+
+// createStairs puts the stairs down in the middle of the last room dug, as
+// far from where the player starts as rooms go.
+func (level *Level) createStairs() {
+	stairs, _, err := ebitenutil.NewImageFromFile("assets/stairs.png")
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	x, y := level.Rooms[len(level.Rooms)-1].Center()
+	tile := level.Tiles[level.GetIndexFromXY(x, y)]
+	tile.TileType = STAIRS
+	tile.Image = stairs
+}
+
+// IsStairs reports whether the tile at x, y has stairs leading down.
+func (level Level) IsStairs(x, y int) bool {
+	return level.InBounds(x, y) && level.Tiles[level.GetIndexFromXY(x, y)].TileType == STAIRS
 }
 
 func (level Level) InBounds(x, y int) bool {
-	gd := NewGameData()
-	if x < 0 || x > gd.ScreenWidth || y < 0 || y > levelHeight {
+	if x < 0 || x >= level.Width || y < 0 || y >= level.Height {
 		return false
 	}
 	return true
 }
 
-func (level Level) IsOpaque(x, y int) bool {
+// IsWall reports whether the tile at x, y is a wall. Anything outside the level counts as wall.
+func (level Level) IsWall(x, y int) bool {
+	if !level.InBounds(x, y) {
+		return true
+	}
+	return level.Tiles[level.GetIndexFromXY(x, y)].TileType == WALL
+}
+
+// tileOpacity is how much each type of tile blocks sight, from 0 (clear) to 1 (solid).
+var tileOpacity = map[TileType]float64{
+	WALL:   1,
+	FLOOR:  0,
+	STAIRS: 0,
+}
+
+// Opacity returns how much the tile at x, y blocks sight.
+func (level Level) Opacity(x, y int) float64 {
 	idx := level.GetIndexFromXY(x, y)
-	return level.Tiles[idx].TileType == WALL
+	return tileOpacity[level.Tiles[idx].TileType]
 }
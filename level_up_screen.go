@@ -0,0 +1,75 @@
+package main
+
+import (
+	"fmt"
+	"image/color"
+
+	"github.com/hajimehoshi/ebiten/v2"
+	"github.com/hajimehoshi/ebiten/v2/inpututil"
+	"github.com/hajimehoshi/ebiten/v2/text"
+	"github.com/hajimehoshi/ebiten/v2/vector"
+)
+
+// LevelUpScreen asks the player to pick a boost for each level they have
+// gone up, each boost with the letter that picks it. It stays until every
+// level up has had its boost.
+type LevelUpScreen struct {
+	Message string
+}
+
+// UpdateLevelUpScreen handles the input of the level up screen.
+func UpdateLevelUpScreen(g *Game) {
+	ls := g.LevelUp
+	for _, k := range inpututil.AppendJustPressedKeys(nil) {
+		choice, ok := letterSlot(k)
+		if !ok {
+			continue
+		}
+		if choice >= len(LevelUpBoosts) {
+			ls.Message = fmt.Sprintf("There is no choice %s.", ItemLetter(choice))
+			return
+		}
+
+		boost := LevelUpBoosts[choice]
+		for _, p := range g.World.Query(g.WorldTags["players"]) {
+			x, ok := p.Entity.GetComponentData(experience)
+			if !ok {
+				continue
+			}
+			xp := x.(*Experience)
+			boost.Apply(xp, p.Components[health].(*Health))
+			xp.Pending--
+			LogMessage(g, fmt.Sprintf("You gain %s.", boost.Description))
+			if xp.Pending <= 0 {
+				g.LevelUp = nil
+				g.Input.Clear()
+			}
+		}
+		return
+	}
+}
+
+// DrawLevelUpScreen draws the level up screen over the game.
+func DrawLevelUpScreen(g *Game, screen *ebiten.Image) {
+	ls := g.LevelUp
+	w, h := screen.Bounds().Dx(), screen.Bounds().Dy()
+	vector.DrawFilledRect(screen, 0, 0, float32(w), float32(h), color.RGBA{A: 230}, false)
+
+	xp := playerExperience(g)
+	fontX := 32
+	fontY := 40
+	title := fmt.Sprintf("You have reached level %d! Choose a boost:", xp.Level-xp.Pending+1)
+	text.Draw(screen, title, mplusNormalFont, fontX, fontY, color.White)
+	fontY += 32
+
+	for i, boost := range LevelUpBoosts {
+		line := fmt.Sprintf("%s - %s: %s", ItemLetter(i), boost.Name, boost.Description)
+		text.Draw(screen, line, mplusNormalFont, fontX, fontY, color.White)
+		fontY += 16
+	}
+
+	if ls.Message != "" {
+		fontY += 16
+		text.Draw(screen, ls.Message, mplusNormalFont, fontX, fontY, selectedColor)
+	}
+}
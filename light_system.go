@@ -0,0 +1,239 @@
+package main
+
+import (
+	"image/color"
+	"math"
+	"math/rand"
+	"time"
+
+	"github.com/laracarvalho/rogolike/fov"
+)
+
+// ambientLight is the light every tile gets, so that unlit tiles the player can
+// see right next to them aren't pitch black.
+const ambientLight = 0.2
+
+// darkThreshold is the light a tile needs before the player can see it from afar.
+const darkThreshold = 0.15
+
+// darkVision is how many tiles around themselves the player can make out without any light.
+const darkVision = 1
+
+// flickerInterval is the number of frames between flickers of the lights.
+const flickerInterval = 6
+
+// flickerRng makes the lights flicker. Flickering goes by frames, not game
+// turns, so it has a generator of its own and leaves the game's rng to the
+// rolls a seed should play out the same.
+var flickerRng = rand.New(rand.NewSource(time.Now().UnixNano()))
+
+// LightMap holds the light falling on every tile of a level, one value per
+// colour channel. It is recomputed only when a light moves or changes, and
+// the colours again whenever the lights flicker. steady is how bright each
+// tile is with every light at full strength, which is what sight goes by, so
+// that what the player can see doesn't change with the flickering.
+type LightMap struct {
+	R []float64
+	G []float64
+	B []float64
+
+	steady    []float64
+	view      *fov.View
+	width     int
+	lastLit   []litSource
+	lastFlick int
+}
+
+// litSource is a light as it was placed on the map when the light map was last computed.
+type litSource struct {
+	X      int
+	Y      int
+	Source LightSource
+}
+
+// NewLightMap creates an empty, completely dark light map.
+func NewLightMap() *LightMap {
+	return &LightMap{view: fov.New()}
+}
+
+// Compute lights the level with the given sources. flicker is the strength
+// each source shines at this time, between 0 and 1.
+func (lm *LightMap) Compute(level Level, sources []litSource, flicker []float64) {
+	n := len(level.Tiles)
+	lm.width = level.Width
+	if len(lm.R) != n {
+		lm.R = make([]float64, n)
+		lm.G = make([]float64, n)
+		lm.B = make([]float64, n)
+	}
+	for i := 0; i < n; i++ {
+		lm.R[i] = 0
+		lm.G[i] = 0
+		lm.B[i] = 0
+	}
+
+	for i, src := range sources {
+		radius := src.Source.Radius
+		lm.view.Compute(level, src.X, src.Y, radius)
+
+		r := float64(src.Source.Color.R) / 255
+		g := float64(src.Source.Color.G) / 255
+		b := float64(src.Source.Color.B) / 255
+
+		for y := src.Y - radius; y <= src.Y+radius; y++ {
+			for x := src.X - radius; x <= src.X+radius; x++ {
+				vis := lm.view.Visibility(x, y)
+				if vis == 0 || !level.InBounds(x, y) {
+					continue
+				}
+				dist := math.Hypot(float64(x-src.X), float64(y-src.Y))
+				intensity := vis * flicker[i] * (1 - dist/float64(radius+1))
+				if intensity <= 0 {
+					continue
+				}
+
+				idx := level.GetIndexFromXY(x, y)
+				lm.R[idx] += r * intensity
+				lm.G[idx] += g * intensity
+				lm.B[idx] += b * intensity
+			}
+		}
+	}
+}
+
+// Brightness returns how well lit the tile at x, y is, from 0 (dark) to 1,
+// leaving out any flickering.
+func (lm *LightMap) Brightness(x, y int) float64 {
+	idx := (y * lm.width) + x
+	if x < 0 || x >= lm.width || idx < 0 || idx >= len(lm.steady) {
+		return ambientLight
+	}
+	return float64(channel(lm.steady[idx]))
+}
+
+// keepSteady remembers how bright every tile is as the light map stands, as
+// the brightness sight goes by.
+func (lm *LightMap) keepSteady() {
+	if len(lm.steady) != len(lm.R) {
+		lm.steady = make([]float64, len(lm.R))
+	}
+	for i := range lm.steady {
+		lm.steady[i] = math.Max(lm.R[i], math.Max(lm.G[i], lm.B[i]))
+	}
+}
+
+// Tint returns the colour scale a tile at x, y is drawn with, including the ambient light.
+func (lm *LightMap) Tint(x, y int) (float32, float32, float32) {
+	idx := (y * lm.width) + x
+	if x < 0 || x >= lm.width || idx < 0 || idx >= len(lm.R) {
+		return ambientLight, ambientLight, ambientLight
+	}
+	return channel(lm.R[idx]), channel(lm.G[idx]), channel(lm.B[idx])
+}
+
+func channel(v float64) float32 {
+	return float32(math.Min(1, ambientLight+v))
+}
+
+// changed reports whether the lights differ from the ones the map was computed with.
+func (lm *LightMap) changed(sources []litSource) bool {
+	if len(sources) != len(lm.lastLit) {
+		return true
+	}
+	for i := range sources {
+		if sources[i] != lm.lastLit[i] {
+			return true
+		}
+	}
+	return false
+}
+
+// UpdateLighting recomputes the light map of the current level when a light has
+// moved, changed or flickered since the last time it was computed. Only a
+// light moving or changing makes the player look again; flickering just
+// changes the colours the tiles are drawn in.
+func UpdateLighting(g *Game) {
+	level := g.Map.CurrentLevel
+	lm := level.Light
+
+	sources := make([]litSource, 0)
+	flickers := false
+	for _, result := range g.World.Query(g.WorldTags["lights"]) {
+		pos := result.Components[position].(*Position)
+		light := result.Components[lightSource].(*LightSource)
+		sources = append(sources, litSource{X: pos.X, Y: pos.Y, Source: *light})
+		if light.Flicker > 0 {
+			flickers = true
+		}
+	}
+
+	changed := lm.changed(sources)
+	lm.lastFlick++
+	flickerDue := flickers && lm.lastFlick >= flickerInterval
+	if !flickerDue && !changed {
+		return
+	}
+
+	strength := make([]float64, len(sources))
+	if changed {
+		for i := range strength {
+			strength[i] = 1
+		}
+		lm.Compute(level, sources, strength)
+		lm.keepSteady()
+		lm.lastLit = sources
+
+		//What the player can see depends on the light, so look again
+		for _, p := range g.World.Query(g.WorldTags["players"]) {
+			pos := p.Components[position].(*Position)
+			level.PlayerVisible.Compute(level, pos.X, pos.Y, playerSightRadius)
+		}
+		level.RevealSeen()
+	}
+	if !flickers {
+		return
+	}
+
+	lm.lastFlick = 0
+	for i, src := range sources {
+		strength[i] = 1
+		if src.Source.Flicker > 0 {
+			strength[i] -= src.Source.Flicker * float64(flickerRng.Intn(100)) / 100
+		}
+	}
+	lm.Compute(level, sources, strength)
+}
+
+// PlayerCanSee reports whether the player can make out the tile at x, y: it has
+// to be in the player's line of sight, and either lit or right next to them.
+func (level Level) PlayerCanSee(x, y int) bool {
+	if !level.PlayerVisible.IsVisible(x, y) {
+		return false
+	}
+	px, py := level.PlayerVisible.Origin()
+	near := Position{X: px, Y: py}
+	if near.GetChebyshevDistance(&Position{X: x, Y: y}) <= darkVision {
+		return true
+	}
+	return level.Light.Brightness(x, y)-ambientLight >= darkThreshold
+}
+
+// RevealSeen marks every tile the player can see as revealed and remembers how it looks.
+func (level Level) RevealSeen() {
+	ox, oy := level.PlayerVisible.Origin()
+	for y := oy - playerSightRadius; y <= oy+playerSightRadius; y++ {
+		for x := ox - playerSightRadius; x <= ox+playerSightRadius; x++ {
+			if level.InBounds(x, y) && level.PlayerCanSee(x, y) {
+				tile := level.Tiles[level.GetIndexFromXY(x, y)]
+				tile.IsRevealed = true
+				tile.Remembered = tile.Image
+			}
+		}
+	}
+}
+
+// torchLight is the warm light of a burning torch or brazier.
+var torchLight = color.RGBA{R: 255, G: 190, B: 110, A: 255}
+
+// graveLight is the cold glow of restless bones.
+var graveLight = color.RGBA{R: 120, G: 255, B: 170, A: 255}
@@ -0,0 +1,166 @@
+package main
+
+import (
+	"github.com/laracarvalho/rogolike/ecs"
+)
+
+// LootEntry is one row of a loot table: an item by name, another table to
+// roll on, or, with neither, nothing at all. Weight is its chance against the
+// other entries, plus PerDepth for every level below the first. It can only
+// come up from MinDepth down to MaxDepth; a MaxDepth of 0 means no limit.
+type LootEntry struct {
+	Item     string
+	Table    *LootTable
+	Weight   int
+	PerDepth int
+	MinDepth int
+	MaxDepth int
+}
+
+// weightAt returns the weight of the entry at the given depth.
+func (e LootEntry) weightAt(depth int) int {
+	if depth < e.MinDepth || (e.MaxDepth > 0 && depth > e.MaxDepth) {
+		return 0
+	}
+	return Max(0, e.Weight+e.PerDepth*(depth-1))
+}
+
+// LootTable is a weighted list of what can be found. Rolls is how many times
+// it is rolled on at once; 0 counts as once.
+type LootTable struct {
+	Entries []LootEntry
+	Rolls   int
+}
+
+// Loot rolls on loot tables and keeps track of the unique items already made.
+type Loot struct {
+	made map[string]bool
+}
+
+// NewLoot creates a Loot for a new game, with no uniques made yet.
+func NewLoot() *Loot {
+	return &Loot{made: make(map[string]bool)}
+}
+
+// Roll returns the names of the items the table comes up with at the given depth.
+func (l *Loot) Roll(t *LootTable, depth int) []string {
+	items := make([]string, 0)
+	for r := 0; r < Max(1, t.Rolls); r++ {
+		total := 0
+		for _, e := range t.Entries {
+			total += e.weightAt(depth)
+		}
+		if total == 0 {
+			continue
+		}
+
+		roll := GetRandomInt(total)
+		for _, e := range t.Entries {
+			roll -= e.weightAt(depth)
+			if roll >= 0 {
+				continue
+			}
+			if e.Table != nil {
+				items = append(items, l.Roll(e.Table, depth)...)
+			} else if e.Item != "" {
+				items = append(items, e.Item)
+			}
+			break
+		}
+	}
+	return items
+}
+
+// Spawn creates the named items lying at x, y, rolling affixes for
+// equipment found at the given depth. A unique item which has been made
+// before comes to nothing.
+func (l *Loot) Spawn(engine *ecs.Engine, items []string, depth int, x int, y int) {
+	for _, label := range items {
+		t, ok := ItemTemplateNamed(label)
+		if !ok || (t.Unique && l.made[label]) {
+			continue
+		}
+		l.made[label] = true
+		SpawnItem(engine, GenerateEquipment(t, depth), x, y)
+	}
+}
+
+// Drops is what a monster leaves behind when it dies: a roll on Table, and
+// every item in Always.
+type Drops struct {
+	Table  *LootTable
+	Always []string
+}
+
+// DropLoot leaves the dead entity's drops on the tile it died on.
+func DropLoot(g *Game, dead *ecs.QueryResult) {
+	d, ok := dead.Entity.GetComponentData(drops)
+	if !ok {
+		return
+	}
+	pos := dead.Components[position].(*Position)
+	items := append([]string{}, d.(*Drops).Always...)
+	if d.(*Drops).Table != nil {
+		items = append(items, g.Loot.Roll(d.(*Drops).Table, g.Map.CurrentLevel.Depth)...)
+	}
+	g.Loot.Spawn(g.World, items, g.Map.CurrentLevel.Depth, pos.X, pos.Y)
+}
+
+// PlaceFloorLoot scatters loot around the rooms of the level, one roll on
+// floorLoot per room except the first, where the player starts.
+func PlaceFloorLoot(engine *ecs.Engine, level Level, loot *Loot) {
+	for _, room := range level.Rooms[1:] {
+		x := room.X + GetDiceRoll(room.Width-room.X-1)
+		y := room.Y + GetDiceRoll(room.Height-room.Y-1)
+		loot.Spawn(engine, loot.Roll(floorLoot, level.Depth), level.Depth, x, y)
+	}
+}
+
+var potionLoot = &LootTable{Entries: []LootEntry{
+	{Item: "Potion of Healing", Weight: 4},
+	{Item: "Potion of Regeneration", Weight: 2},
+	{Item: "Potion of Poison", Weight: 1},
+	{Item: "Potion of Slowness", Weight: 1},
+}}
+
+var scrollLoot = &LootTable{Entries: []LootEntry{
+	{Item: "Scroll of Teleport", Weight: 3},
+	{Item: "Scroll of Mapping", Weight: 3},
+	{Item: "Scroll of Fireball", Weight: 1, PerDepth: 1},
+	{Item: "Scroll of Lightning", Weight: 2, PerDepth: 1},
+	{Item: "Scroll of Identify", Weight: 4},
+	{Item: "Scroll of Blessing", Weight: 2},
+}}
+
+var gearLoot = &LootTable{Entries: []LootEntry{
+	{Item: "Dagger", Weight: 3},
+	{Item: "Mace", Weight: 2},
+	{Item: "Short Bow", Weight: 2},
+	{Item: "Throwing Daggers", Weight: 2},
+	{Item: "Wooden Shield", Weight: 2},
+	{Item: "Leather Cap", Weight: 2},
+	{Item: "Ring of Accuracy", Weight: 1, PerDepth: 1},
+	{Item: "Ring of Protection", Weight: 1, PerDepth: 1},
+}}
+
+// floorLoot is what lies around the rooms of a level.
+var floorLoot = &LootTable{Entries: []LootEntry{
+	{Weight: 4},
+	{Item: "Gold Coins", Weight: 4},
+	{Item: "Torch", Weight: 1, MaxDepth: 3},
+	{Item: "Rope", Weight: 1, MaxDepth: 3},
+	{Item: "Arrows", Weight: 2},
+	{Table: potionLoot, Weight: 3},
+	{Table: scrollLoot, Weight: 3},
+	{Table: gearLoot, Weight: 2, PerDepth: 1},
+}}
+
+// skeletonLoot is what skeletons drop.
+var skeletonLoot = &LootTable{Entries: []LootEntry{
+	{Weight: 6},
+	{Item: "Gold Coins", Weight: 3},
+	{Item: "Arrows", Weight: 1},
+	{Table: potionLoot, Weight: 1},
+	{Table: gearLoot, Weight: 1},
+	{Item: "Bone Crown", Weight: 1},
+}}
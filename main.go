@@ -1,8 +1,10 @@
 package main
 
 import (
+	"flag"
 	_ "image/png"
 	"log"
+	"time"
 
 	"github.com/hajimehoshi/ebiten/v2"
 	"github.com/laracarvalho/rogolike/ecs"
@@ -10,30 +12,90 @@ import (
 
 // Game holds all data the entire game will need.
 type Game struct {
-	Map         GameMap
-	World       *ecs.Engine
-	WorldTags   map[string]ecs.Tag
-	Turn        TurnState
-	TurnCounter int
+	Map          GameMap
+	World        *ecs.Engine
+	WorldTags    map[string]ecs.Tag
+	Turn         TurnState
+	Input        *InputBuffer
+	Scheduler    *Scheduler
+	Movement     MovementRules
+	MonsterMaps  *MonsterMaps
+	DangerZones  []DangerZone
+	Bindings     KeyBindings
+	BindingsPath string
+	Rebind       *RebindScreen
+	Inventory    *InventoryScreen
+	Targeting    *Targeting
+	LevelUp      *LevelUpScreen
+	WaitPrompt   *WaitPrompt
+	Loot         *Loot
+	Identify     *Identification
+	Activity     Activity
+
+	activityWatch *activityWatch
+	queuedAction  Action
 }
 
 // NewGame creates a new Game Object and initializes the data
 // This is a pretty solid refactor candidate for later
-func NewGame() *Game {
+func NewGame(movement MovementRules, bindings KeyBindings, bindingsPath string, input InputSettings) *Game {
 	g := &Game{}
+	g.Movement = movement
+	g.Bindings = bindings
+	g.BindingsPath = bindingsPath
+	g.Input = NewInputBuffer(input)
+	g.MonsterMaps = NewMonsterMaps(movement, WithPenalties(WalkableCost, AvoidZones(&g.DangerZones)))
 	g.Map = NewGameMap()
-	world, tags := InitializeWorld(g.Map.CurrentLevel)
+	g.Loot = NewLoot()
+	g.Identify = NewIdentification()
+	world, tags := InitializeWorld(g.Map.CurrentLevel, g.Loot)
 	g.WorldTags = tags
 	g.World = world
+	g.Scheduler = NewScheduler()
+	for _, p := range g.World.Query(g.WorldTags["players"]) {
+		g.Scheduler.Add(p.Entity.ID, 0)
+	}
+	for _, m := range g.World.Query(g.WorldTags["monsters"]) {
+		g.Scheduler.Add(m.Entity.ID, 0)
+	}
 	g.Turn = PlayerTurn
-	g.TurnCounter = 0
 	return g
 }
 
 // Update is called each tic.
 func (g *Game) Update() error {
-	g.TurnCounter++
-	if g.Turn == PlayerTurn && g.TurnCounter > 5 {
+	if g.Rebind != nil {
+		UpdateRebindScreen(g)
+		return nil
+	}
+	if g.Inventory != nil {
+		UpdateInventoryScreen(g)
+		return nil
+	}
+	if g.LevelUp != nil {
+		UpdateLevelUpScreen(g)
+		return nil
+	}
+	if g.WaitPrompt != nil {
+		UpdateWaitPrompt(g)
+		return nil
+	}
+
+	UpdateLighting(g)
+
+	if g.Targeting != nil {
+		g.Input.Poll(g.Bindings)
+		UpdateTargeting(g)
+		return nil
+	}
+
+	UpdateRegeneration(g)
+	UpdateStatuses(g)
+	ExpireDangerZones(&g.DangerZones, g.Scheduler.GameTurn())
+
+	g.Input.Poll(g.Bindings)
+	HandleMouse(g)
+	if g.Turn == PlayerTurn {
 		TakePlayerAction(g)
 	}
 
@@ -53,6 +115,23 @@ func (g *Game) Draw(screen *ebiten.Image) {
 	ProcessRenderables(g, level, screen)
 	ProcessUserLog(g, screen)
 	ProcessHUD(g, screen)
+	DrawTooltip(g, screen)
+
+	if g.Targeting != nil {
+		DrawTargeting(g, screen)
+	}
+	if g.WaitPrompt != nil {
+		DrawWaitPrompt(g, screen)
+	}
+	if g.Inventory != nil {
+		DrawInventoryScreen(g, screen)
+	}
+	if g.LevelUp != nil {
+		DrawLevelUpScreen(g, screen)
+	}
+	if g.Rebind != nil {
+		DrawRebindScreen(g, screen)
+	}
 }
 
 // Layout will return the screen dimensions.
@@ -63,8 +142,30 @@ func (g *Game) Layout(w, h int) (int, int) {
 }
 
 func main() {
+	movement := MovementRules{}
+	flag.BoolVar(&movement.Diagonal, "diagonal", false, "allow 8-way movement")
+	flag.BoolVar(&movement.CornerCutting, "corner-cutting", false, "allow diagonal steps past the corner of a wall")
+	flag.BoolVar(&movement.Octile, "octile", false, "make diagonal steps cost more than straight ones when pathfinding")
+	bindingsPath := flag.String("bindings", DefaultBindingsPath(), "key bindings file")
+	input := DefaultInputSettings()
+	flag.IntVar(&input.RepeatDelay, "repeat-delay", input.RepeatDelay, "ticks a key is held before it repeats")
+	flag.IntVar(&input.RepeatInterval, "repeat-interval", input.RepeatInterval, "ticks between repeats of a held key")
+	seed := flag.Int64("seed", 0, "seed for the random number generator, 0 for a random one")
+	flag.Parse()
+
+	if *seed == 0 {
+		*seed = time.Now().UnixNano()
+	}
+	SeedRandom(*seed)
+	log.Printf("Seed: %d", *seed)
+
+	bindings, err := LoadKeyBindings(*bindingsPath)
+	if err != nil {
+		log.Printf("Using default key bindings: %v", err)
+		bindings = DefaultKeyBindings()
+	}
 
-	g := NewGame()
+	g := NewGame(movement, bindings, *bindingsPath, input)
 	ebiten.SetWindowResizingMode(ebiten.WindowResizingModeEnabled)
 
 	ebiten.SetWindowTitle("Rogolike")
@@ -9,7 +9,7 @@ type GameMap struct {
 //NewGameMap creates a new set of maps for the entire game.
 func NewGameMap() GameMap {
 	//Return a new game map of a single level for now
-	l := NewLevel()
+	l := NewLevel(1)
 	levels := make([]Level, 0)
 	levels = append(levels, l)
 	d := Dungeon{Name: "default", Levels: levels}
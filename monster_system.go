@@ -1,9 +1,29 @@
 package main
 
 import (
-	"github.com/norendren/go-fov/fov"
+	"github.com/laracarvalho/rogolike/ecs"
+	"github.com/laracarvalho/rogolike/fov"
 )
 
+// monsterFleeDivisor decides when a monster runs: at or below
+// MaxHealth / monsterFleeDivisor it flees from the player instead of closing in.
+const monsterFleeDivisor = 4
+
+// monsterSearchBudget caps how many nodes a monster's path search may expand
+// in a single turn.
+const monsterSearchBudget = 1000
+
+// allyPenalty is the extra cost, in straight steps, a monster accepts to walk
+// around another monster rather than wait behind it.
+const allyPenalty = 5 * straightStepCost
+
+// itemSeekRange is how many steps a monster which can't see the player will
+// walk to stand guard over an item lying on the floor.
+const itemSeekRange = 6
+
+// monsterSees is the field of view buffer every monster reuses in turn.
+var monsterSees = fov.New()
+
 func UpdateMonster(game *Game) {
 	l := game.Map.CurrentLevel
 	playerPosition := Position{}
@@ -14,38 +34,107 @@ func UpdateMonster(game *Game) {
 		playerPosition.Y = pos.Y
 	}
 
-	for _, result := range game.World.Query(game.WorldTags["monsters"]) {
-		pos := result.Components[position].(*Position)
-
-		monsterSees := fov.New()
-		monsterSees.Compute(l, pos.X, pos.Y, 8)
-
-		if monsterSees.IsVisible(playerPosition.X, playerPosition.Y) {
-			if pos.GetManhattanDistance(&playerPosition) == 1 {
-				//The monster is right next to the player. Just smack him down
-				AttackSystem(game, pos, &playerPosition)
-				if result.Components[health].(*Health).CurrentHealth <= 0 {
-					//this monster is dead
-					//clear the tile
-					t := l.Tiles[l.GetIndexFromXY(pos.X, pos.Y)]
-					t.Blocked = false
-				}
-			} else {
-				astar := AStar{}
-				path := astar.GetPath(l, pos, &playerPosition)
-				if len(path) > 1 {
-					nextTile := l.Tiles[l.GetIndexFromXY(path[1].X, path[1].Y)]
-					if !nextTile.Blocked {
-						l.Tiles[l.GetIndexFromXY(pos.X, pos.Y)].Blocked = false
-						pos.X = path[1].X
-						pos.Y = path[1].Y
-						nextTile.Blocked = true
-					}
-				}
-			}
+	items := make([]Position, 0)
+	for _, result := range game.World.Query(game.WorldTags["items"]) {
+		items = append(items, *result.Components[position].(*Position))
+	}
+
+	//Every monster shares the same distance fields this turn
+	game.MonsterMaps.Update(l, playerPosition, items)
+
+	//Let every monster due before the player act, in the order the scheduler says
+	for GetNextState(game) == MonsterTurn {
+		id, _ := game.Scheduler.Peek()
+		result := game.World.GetEntityByID(id, game.WorldTags["monsters"])
+		if result == nil || result.Components[health].(*Health).CurrentHealth <= 0 {
+			//Dead monsters don't get another turn
+			game.Scheduler.Next()
+			continue
+		}
+
+		if !TakeTurn(game, result, monsterIntent(game, l, result, &playerPosition)) {
+			//The monster couldn't do what it wanted, so it waits instead
+			TakeTurn(game, result, WaitAction{})
+		}
+	}
+
+	game.Turn = GetNextState(game)
+}
+
+// monsterIntent decides what a single monster wants to do on its turn.
+func monsterIntent(game *Game, l Level, result *ecs.QueryResult, playerPosition *Position) Action {
+	pos := result.Components[position].(*Position)
+	h := result.Components[health].(*Health)
+
+	isFree := func(x int, y int) bool {
+		return !l.Tiles[l.GetIndexFromXY(x, y)].Blocked
+	}
+
+	monsterSees.Compute(l, pos.X, pos.Y, 8)
+
+	if !monsterSees.IsVisible(playerPosition.X, playerPosition.Y) {
+		return seekItem(game, l, pos, isFree)
+	}
+
+	fleeing := h.CurrentHealth <= h.MaxHealth/monsterFleeDivisor
+
+	if !fleeing && game.Movement.IsAdjacent(l, pos, playerPosition) {
+		//The monster is right next to the player. Just smack him down
+		return AttackAction{Target: *playerPosition}
+	}
+
+	if wpn, _ := CombatRanged(game, result.Entity); wpn != nil && !fleeing && pos.GetChebyshevDistance(playerPosition) <= wpn.Range {
+		//Shoot from where it stands if nothing is in the way
+		if _, _, clear := LineOfFire(game, pos, playerPosition); clear {
+			return RangedAttackAction{Target: *playerPosition}
 		}
+	}
+
+	field := game.MonsterMaps.ToPlayer
+	if fleeing {
+		field = game.MonsterMaps.FromPlayer
+	}
+	next, ok := field.RollDownhill(l, pos, isFree)
+	if !ok && !fleeing {
+		//Someone is in the way, look for a route around them
+		next, ok = routeAround(game, l, pos, playerPosition)
+	}
+	if ok {
+		return MoveAction{DX: next.X - pos.X, DY: next.Y - pos.Y}
+	}
 
+	return WaitAction{}
+}
+
+// seekItem has a monster head for the nearest item within itemSeekRange steps
+// and wait once it stands over it.
+func seekItem(game *Game, l Level, pos *Position, isFree func(x int, y int) bool) Action {
+	field := game.MonsterMaps.ToItems
+	dist := field.Get(pos.X, pos.Y)
+	if dist == 0 || dist > itemSeekRange*straightStepCost {
+		return WaitAction{}
+	}
+	if next, ok := field.RollDownhill(l, pos, isFree); ok {
+		return MoveAction{DX: next.X - pos.X, DY: next.Y - pos.Y}
 	}
+	return WaitAction{}
+}
 
-	game.Turn = PlayerTurn
+// routeAround searches for a path to the goal which treats tiles taken by other
+// monsters as expensive rather than impassable, and returns its first step if
+// that step is free.
+func routeAround(game *Game, l Level, pos *Position, goal *Position) (Position, bool) {
+	astar := AStar{
+		MaxNodes: monsterSearchBudget,
+		Rules:    game.Movement,
+		Cost:     WithPenalties(WalkableCost, OccupiedPenalty(allyPenalty), AvoidZones(&game.DangerZones)),
+	}
+	path := astar.GetPath(l, pos, goal)
+	if len(path) < 2 {
+		return Position{}, false
+	}
+	if l.Tiles[l.GetIndexFromXY(path[1].X, path[1].Y)].Blocked {
+		return Position{}, false
+	}
+	return path[1], true
 }
@@ -0,0 +1,58 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/laracarvalho/rogolike/ecs"
+)
+
+// itemLevel is a level with the player walled off from the monsters.
+var itemLevel = []string{
+	"######################",
+	"#@.#.................#",
+	"#..#.................#",
+	"######################",
+}
+
+// monsterAt makes a monster in full health standing at x, y.
+func monsterAt(engine *ecs.Engine, x int, y int) *ecs.QueryResult {
+	pos := &Position{X: x, Y: y}
+	hp := &Health{MaxHealth: 10, CurrentHealth: 10}
+	e := engine.NewEntity().AddComponent(position, pos).AddComponent(health, hp)
+	return &ecs.QueryResult{
+		Entity:     e,
+		Components: map[*ecs.Component]interface{}{position: pos, health: hp},
+	}
+}
+
+func TestMonsterSeeksItems(t *testing.T) {
+	engine := ecs.NewEngine()
+	position = engine.NewComponent()
+	health = engine.NewComponent()
+
+	level := testLevel(itemLevel)
+	g := &Game{World: engine, MonsterMaps: NewMonsterMaps(MovementRules{}, WalkableCost)}
+	playerPosition := Position{X: 1, Y: 1}
+	g.MonsterMaps.Update(level, playerPosition, []Position{{X: 5, Y: 2}})
+
+	near := monsterAt(engine, 8, 1)
+	move, ok := monsterIntent(g, level, near, &playerPosition).(MoveAction)
+	if !ok {
+		t.Fatalf("a monster four steps from an item doesn't move")
+	}
+	pos := near.Components[position].(*Position)
+	before := g.MonsterMaps.ToItems.Get(pos.X, pos.Y)
+	if after := g.MonsterMaps.ToItems.Get(pos.X+move.DX, pos.Y+move.DY); after >= before {
+		t.Errorf("a monster four steps from an item moves %+v, away from it", move)
+	}
+
+	on := monsterAt(engine, 5, 2)
+	if a := monsterIntent(g, level, on, &playerPosition); a != (WaitAction{}) {
+		t.Errorf("a monster standing over an item wants to %T", a)
+	}
+
+	far := monsterAt(engine, 20, 1)
+	if a := monsterIntent(g, level, far, &playerPosition); a != (WaitAction{}) {
+		t.Errorf("a monster sixteen steps from an item wants to %T", a)
+	}
+}
@@ -0,0 +1,171 @@
+package main
+
+import (
+	"fmt"
+	"image/color"
+	"strings"
+
+	"github.com/hajimehoshi/ebiten/v2"
+	"github.com/hajimehoshi/ebiten/v2/inpututil"
+	"github.com/hajimehoshi/ebiten/v2/text"
+	"github.com/hajimehoshi/ebiten/v2/vector"
+	"github.com/laracarvalho/rogolike/ecs"
+)
+
+var tooltipBackground = color.RGBA{R: 20, G: 20, B: 30, A: 220}
+
+// cursorTile returns the map tile under the mouse cursor. The second return
+// value is false when the cursor is outside the map, over the UI for example.
+func cursorTile() (int, int, bool) {
+	gd := NewGameData()
+	cx, cy := ebiten.CursorPosition()
+	x := cx / gd.TileWidth
+	y := cy / gd.TileHeight
+	if cx < 0 || cy < 0 || x >= gd.ScreenWidth || y >= levelHeight {
+		return 0, 0, false
+	}
+	return x, y, true
+}
+
+// HandleMouse scrolls the message log with the wheel and, on the player's turn,
+// sets off travelling to a clicked tile.
+func HandleMouse(g *Game) {
+	if _, wy := ebiten.Wheel(); wy != 0 {
+		if wy > 0 {
+			ScrollUserLog(1)
+		} else {
+			ScrollUserLog(-1)
+		}
+	}
+
+	if g.Turn != PlayerTurn || !inpututil.IsMouseButtonJustPressed(ebiten.MouseButtonLeft) {
+		return
+	}
+
+	x, y, ok := cursorTile()
+	if !ok {
+		return
+	}
+	level := g.Map.CurrentLevel
+	tile := level.Tiles[level.GetIndexFromXY(x, y)]
+	if !tile.IsRevealed || tile.TileType == WALL {
+		return
+	}
+
+	for _, p := range g.World.Query(g.WorldTags["players"]) {
+		pos := p.Components[position].(*Position)
+		if travel := NewTravelActivity(g, pos, &Position{X: x, Y: y}); travel != nil {
+			StartActivity(g, travel)
+		}
+	}
+}
+
+// DrawTooltip describes what is under the mouse cursor, if the player can see it.
+func DrawTooltip(g *Game, screen *ebiten.Image) {
+	x, y, ok := cursorTile()
+	if !ok {
+		return
+	}
+	level := g.Map.CurrentLevel
+	if !level.PlayerCanSee(x, y) {
+		return
+	}
+
+	lines := make([]string, 0)
+	for _, result := range g.World.Query(g.WorldTags["renderables"]) {
+		pos := result.Components[position].(*Position)
+		if pos.X != x || pos.Y != y {
+			continue
+		}
+		lines = append(lines, describeEntity(g, result.Entity)...)
+	}
+	if level.IsStairs(x, y) {
+		lines = append(lines, "Stairs down")
+	}
+	if len(lines) == 0 {
+		return
+	}
+
+	width := 0
+	for _, l := range lines {
+		width = Max(width, text.BoundString(mplusNormalFont, l).Dx())
+	}
+	height := 16*len(lines) + 8
+
+	cx, cy := ebiten.CursorPosition()
+	bx := cx + 16
+	by := cy + 16
+	sw, sh := screen.Bounds().Dx(), screen.Bounds().Dy()
+	if bx+width+16 > sw {
+		bx = cx - width - 24
+	}
+	if by+height > sh {
+		by = cy - height
+	}
+
+	vector.DrawFilledRect(screen, float32(bx), float32(by), float32(width+16), float32(height), tooltipBackground, false)
+	fontY := by + 18
+	for _, l := range lines {
+		text.Draw(screen, l, mplusNormalFont, bx+8, fontY, color.White)
+		fontY += 16
+	}
+}
+
+// describeEntity returns the tooltip lines for an entity: its name, its
+// health, weapon and armor if it has them, and the status effects it is
+// under. The stats of items the player hasn't identified are hidden.
+func describeEntity(g *Game, e *ecs.Entity) []string {
+	lines := make([]string, 0)
+	if e.HasComponent(item) {
+		lines = append(lines, ItemName(g, e))
+		if !IsIdentified(g, e) {
+			return append(lines, "Unidentified")
+		}
+	} else if n, ok := e.GetComponentData(name); ok {
+		lines = append(lines, n.(*Name).Label)
+	}
+	if h, ok := e.GetComponentData(health); ok {
+		hp := h.(*Health)
+		lines = append(lines, fmt.Sprintf("Health: %d / %d", hp.CurrentHealth, hp.MaxHealth))
+	}
+	wpn, ranged, ac := gearOf(g, e)
+	if wpn != nil {
+		lines = append(lines, fmt.Sprintf("Weapon: %s (%d - %d %s, %+d to hit)", wpn.Name, wpn.MinimumDamage, wpn.MaximumDamage, wpn.DamageType, wpn.ToHitBonus))
+	}
+	if ranged != nil {
+		lines = append(lines, fmt.Sprintf("Ranged: %s (%d - %d %s, %+d to hit, range %d)", ranged.Name, ranged.MinimumDamage, ranged.MaximumDamage, ranged.DamageType, ranged.ToHitBonus, ranged.Range))
+	}
+	if ac != nil {
+		lines = append(lines, fmt.Sprintf("Armor: %s (AC %d, Defense %d)", ac.Name, ac.ArmorClass, ac.Defense))
+	}
+	for _, kind := range StatusKinds {
+		if s, ok := StatusOf(e, kind); ok {
+			lines = append(lines, fmt.Sprintf("%s%s (%d turns)", strings.ToUpper(kind.String()[:1]), kind.String()[1:], s.Turns))
+		}
+	}
+	return lines
+}
+
+// gearOf returns the weapons and armor to describe for an entity, nil for
+// those it doesn't have. Creatures are described by what they fight with,
+// as the HUD shows it; items by their own stats.
+func gearOf(g *Game, e *ecs.Entity) (*MeleeWeapon, *RangedWeapon, *Armor) {
+	if e.HasComponent(item) || !e.HasComponent(health) {
+		var wpn *MeleeWeapon
+		var ranged *RangedWeapon
+		var ac *Armor
+		if w, ok := e.GetComponentData(meleeWeapon); ok {
+			wpn = w.(*MeleeWeapon)
+		}
+		if w, ok := e.GetComponentData(rangedWeapon); ok {
+			ranged = w.(*RangedWeapon)
+		}
+		if a, ok := e.GetComponentData(armor); ok {
+			ac = a.(*Armor)
+		}
+		return wpn, ranged, ac
+	}
+
+	ranged, _ := CombatRanged(g, e)
+	return CombatWeapon(g, e), ranged, CombatArmor(g, e)
+}
@@ -0,0 +1,86 @@
+package main
+
+// Step costs used by path searches. A cardinal step always costs
+// straightStepCost; diagonal steps cost either the same (every step is one
+// move, Chebyshev distance) or roughly straightStepCost * sqrt(2) (octile
+// distance, which prefers straight lines).
+const (
+	straightStepCost = 10
+	octileStepCost   = 14
+)
+
+// MovementRules holds the movement options chosen at game start.
+// Diagonal allows 8-way movement, CornerCutting allows diagonal steps that
+// squeeze past the corner of a wall, and Octile makes diagonal steps cost more
+// than straight ones when searching for paths.
+type MovementRules struct {
+	Diagonal      bool
+	CornerCutting bool
+	Octile        bool
+}
+
+var diagonalDirections = []Position{
+	{X: -1, Y: -1},
+	{X: 1, Y: -1},
+	{X: -1, Y: 1},
+	{X: 1, Y: 1},
+}
+
+var allDirections = append(append([]Position{}, cardinalDirections...), diagonalDirections...)
+
+// Directions returns the offsets an actor may step in under these rules.
+func (r MovementRules) Directions() []Position {
+	if r.Diagonal {
+		return allDirections
+	}
+	return cardinalDirections
+}
+
+// CanStep reports whether the step from (x, y) by (dx, dy) is allowed by the
+// rules, ignoring what occupies the destination. Walls are never steppable.
+func (r MovementRules) CanStep(level Level, x int, y int, dx int, dy int) bool {
+	if dx == 0 && dy == 0 {
+		return false
+	}
+	if dx < -1 || dx > 1 || dy < -1 || dy > 1 {
+		return false
+	}
+	if !level.InBounds(x+dx, y+dy) {
+		return false
+	}
+	if dx != 0 && dy != 0 {
+		if !r.Diagonal {
+			return false
+		}
+		if !r.CornerCutting && (level.IsWall(x+dx, y) || level.IsWall(x, y+dy)) {
+			return false
+		}
+	}
+	return !level.IsWall(x+dx, y+dy)
+}
+
+// StepCost returns the path cost of a single step by (dx, dy).
+func (r MovementRules) StepCost(dx int, dy int) int {
+	if dx != 0 && dy != 0 && r.Octile {
+		return octileStepCost
+	}
+	return straightStepCost
+}
+
+// Heuristic estimates the path cost between two positions in the same units
+// as StepCost.
+func (r MovementRules) Heuristic(from *Position, to *Position) int {
+	if !r.Diagonal {
+		return from.GetManhattanDistance(to) * straightStepCost
+	}
+	if r.Octile {
+		return from.GetOctileDistance(to, straightStepCost, octileStepCost)
+	}
+	return from.GetChebyshevDistance(to) * straightStepCost
+}
+
+// IsAdjacent reports whether an actor at from could step onto to, which is
+// also the reach of a melee attack.
+func (r MovementRules) IsAdjacent(level Level, from *Position, to *Position) bool {
+	return r.CanStep(level, from.X, from.Y, to.X-from.X, to.Y-from.Y)
+}
@@ -0,0 +1,90 @@
+package main
+
+// TileCost returns the cost of entering the tile at x, y, in the same units as
+// MovementRules.StepCost for a straight step. A negative cost means the tile
+// can't be entered. Path searches scale it by the step cost, so diagonal steps
+// stay dearer than straight ones under octile rules.
+type TileCost func(level Level, x int, y int) int
+
+// TilePenalty returns extra cost for entering the tile at x, y on top of what
+// the terrain costs. Penalties are never negative, so they steer a path away
+// from a tile without ever making it impassable.
+type TilePenalty func(level Level, x int, y int) int
+
+// WalkableCost is the TileCost of plain terrain: every tile that isn't a wall
+// costs a single step.
+func WalkableCost(level Level, x int, y int) int {
+	if level.IsWall(x, y) {
+		return -1
+	}
+	return straightStepCost
+}
+
+// WithPenalties builds a TileCost that adds every penalty to base. Tiles base
+// can't enter stay impassable.
+func WithPenalties(base TileCost, penalties ...TilePenalty) TileCost {
+	return func(level Level, x int, y int) int {
+		cost := base(level, x, y)
+		if cost < 0 {
+			return cost
+		}
+		for _, p := range penalties {
+			cost += p(level, x, y)
+		}
+		return cost
+	}
+}
+
+// OccupiedPenalty charges penalty for entering a tile something is standing on.
+// A path may still run through an ally when every other way round is longer.
+func OccupiedPenalty(penalty int) TilePenalty {
+	return func(level Level, x int, y int) int {
+		tile := level.Tiles[level.GetIndexFromXY(x, y)]
+		if tile.Blocked && tile.TileType != WALL {
+			return penalty
+		}
+		return 0
+	}
+}
+
+// DangerZone is an area actors should keep out of, such as a burning patch of
+// floor. Tiles within Radius steps (Chebyshev) of Center cost Penalty extra.
+// The zone lasts until game turn Expires, or for ever if Expires is 0.
+type DangerZone struct {
+	Center  Position
+	Radius  int
+	Penalty int
+	Expires int
+}
+
+// ExpireDangerZones drops the zones which have run out by game turn now.
+func ExpireDangerZones(zones *[]DangerZone, now int) {
+	kept := (*zones)[:0]
+	for _, dz := range *zones {
+		if dz.Expires == 0 || dz.Expires > now {
+			kept = append(kept, dz)
+		}
+	}
+	*zones = kept
+}
+
+// Contains reports whether x, y lies inside the zone.
+func (dz DangerZone) Contains(x int, y int) bool {
+	p := Position{X: x, Y: y}
+	return dz.Center.GetChebyshevDistance(&p) <= dz.Radius
+}
+
+// AvoidZones charges the penalty of every zone covering a tile. The zones are
+// read each time the cost is evaluated, so the slice may change between
+// searches.
+func AvoidZones(zones *[]DangerZone) TilePenalty {
+	return func(level Level, x int, y int) int {
+		cost := 0
+		for _, dz := range *zones {
+			if dz.Contains(x, y) {
+				cost += dz.Penalty
+			}
+		}
+		return cost
+	}
+}
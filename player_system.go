@@ -1,60 +1,86 @@
 package main
 
-import "github.com/hajimehoshi/ebiten/v2"
+// playerSightRadius is how far the player can see, given enough light.
+const playerSightRadius = 8
 
 func TakePlayerAction(g *Game) {
-	players := g.WorldTags["players"]
-	turnTaken := false
+	if g.Activity != nil && g.Input.Pending() {
+		//A key press takes over from whatever the player was busy with
+		StopActivity(g, "")
+	}
 
-	x := 0
-	y := 0
+	turnTaken := false
+	for _, result := range g.World.Query(g.WorldTags["players"]) {
+		action := readPlayerAction(g)
+		if action == nil && g.Activity != nil {
+			action = nextActivityAction(g, result)
+		}
+		if action == nil {
+			return
+		}
 
-	if ebiten.IsKeyPressed(ebiten.KeyUp) {
-		y = -1
+		turnTaken = TakeTurn(g, result, action)
+		if !turnTaken {
+			StopActivity(g, "")
+		}
 	}
 
-	if ebiten.IsKeyPressed(ebiten.KeyDown) {
-		y = 1
+	if turnTaken {
+		g.Turn = GetNextState(g)
 	}
+}
 
-	if ebiten.IsKeyPressed(ebiten.KeyLeft) {
-		x = -1
+// readPlayerAction takes the next buffered command and turns it into the
+// action the player wants to take, or nil if there is none.
+func readPlayerAction(g *Game) Action {
+	if g.queuedAction != nil {
+		action := g.queuedAction
+		g.queuedAction = nil
+		return action
 	}
 
-	if ebiten.IsKeyPressed(ebiten.KeyRight) {
-		x = 1
+	cmd, ok := g.Input.Next()
+	if !ok {
+		return nil
 	}
-	if ebiten.IsKeyPressed(ebiten.KeyQ) {
-		turnTaken = true
-	}
-
-	level := g.Map.CurrentLevel
-
-	for _, result := range g.World.Query(players) {
-		pos := result.Components[position].(*Position)
-		index := level.GetIndexFromXY(pos.X+x, pos.Y+y)
-
-		tile := level.Tiles[index]
-		if tile.Blocked != true {
-			level.Tiles[level.GetIndexFromXY(pos.X, pos.Y)].Blocked = false
-			pos.X += x
-			pos.Y += y
-			level.Tiles[index].Blocked = true
-			level.PlayerVisible.Compute(level, pos.X, pos.Y, 8)
 
-		} else if x != 0 || y != 0 {
-			if level.Tiles[index].TileType != WALL {
-				//Its a tile with a monster -- Fight it
-				monsterPosition := Position{X: pos.X + x, Y: pos.Y + y}
-
-				AttackSystem(g, pos, &monsterPosition)
-			}
+	if dir, ok := commandDirections[cmd]; ok {
+		if dir.X != 0 && dir.Y != 0 && !g.Movement.Diagonal {
+			//Diagonal keys do nothing in 4-way mode
+			return nil
 		}
-
+		return MoveAction{DX: dir.X, DY: dir.Y}
 	}
 
-	if x != 0 || y != 0 || turnTaken {
-		g.Turn = GetNextState(g.Turn)
-		g.TurnCounter = 0
+	switch cmd {
+	case CommandWait:
+		return WaitAction{}
+	case CommandWaitTurns:
+		OpenWaitPrompt(g)
+	case CommandRest:
+		StartResting(g, 0, true)
+	case CommandExplore:
+		StartExploring(g)
+	case CommandPickUp:
+		return PickUpAction{}
+	case CommandDrop:
+		OpenInventory(g, InventoryDrop)
+	case CommandInventory:
+		OpenInventory(g, InventoryBrowse)
+	case CommandEquip:
+		OpenInventory(g, InventoryEquip)
+	case CommandUnequip:
+		OpenInventory(g, InventoryUnequip)
+	case CommandUse:
+		OpenInventory(g, InventoryUse)
+	case CommandFire:
+		StartFiring(g)
+	case CommandDescend:
+		return DescendAction{}
+	case CommandKeyBindings:
+		g.Rebind = &RebindScreen{}
+		g.Input.Clear()
 	}
+
+	return nil
 }
@@ -0,0 +1,246 @@
+package main
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/laracarvalho/rogolike/ecs"
+)
+
+// ActionCostShoot is the time it takes to shoot or throw a ranged weapon.
+const ActionCostShoot = 100
+
+// coverBonus is what each thing in the way of a shot adds to the armor class
+// of its target: a creature standing in the line of fire, or a wall corner
+// the shot has to squeeze past.
+const coverBonus = 2
+
+// Line returns the tiles on the straight line from one position to another,
+// both ends included, as Bresenham's algorithm draws it.
+func Line(from Position, to Position) []Position {
+	dx, sx := to.X-from.X, 1
+	if dx < 0 {
+		dx, sx = -dx, -1
+	}
+	dy, sy := to.Y-from.Y, 1
+	if dy < 0 {
+		dy, sy = -dy, -1
+	}
+
+	line := []Position{from}
+	x, y := from.X, from.Y
+	err := dx - dy
+	for x != to.X || y != to.Y {
+		e2 := 2 * err
+		if e2 > -dy {
+			err -= dy
+			x += sx
+		}
+		if e2 < dx {
+			err += dx
+			y += sy
+		}
+		line = append(line, Position{X: x, Y: y})
+	}
+	return line
+}
+
+// LineOfFire traces a shot from one position to another. It returns the tiles
+// the shot passes through, the cover the target gets from what is in the way,
+// and whether anything opaque stops the shot before it gets there.
+func LineOfFire(g *Game, from *Position, to *Position) ([]Position, int, bool) {
+	level := g.Map.CurrentLevel
+	line := Line(*from, *to)
+	cover := 0
+	corner := false
+
+	for i := 1; i < len(line); i++ {
+		prev, p := line[i-1], line[i]
+		if level.Opacity(p.X, p.Y) >= 1 {
+			return line[:i+1], cover, false
+		}
+		dx, dy := p.X-prev.X, p.Y-prev.Y
+		if dx != 0 && dy != 0 && (level.IsWall(prev.X+dx, prev.Y) || level.IsWall(prev.X, prev.Y+dy)) {
+			//Squeezing past a corner only counts once
+			corner = true
+		}
+		if i < len(line)-1 && ActorAt(g, p.X, p.Y) != nil {
+			cover += coverBonus
+		}
+	}
+	if corner {
+		cover += coverBonus
+	}
+	return line, cover, true
+}
+
+// findAmmo returns the stack of ammunition with the given name the entity
+// carries, or nil.
+func findAmmo(g *Game, e *ecs.Entity, ammo string) *ecs.Entity {
+	inv, ok := e.GetComponentData(inventory)
+	if !ok {
+		return nil
+	}
+	for _, carried := range inv.(*Inventory).Entities(g) {
+		if n, ok := carried.GetComponentData(name); ok && n.(*Name).Label == ammo {
+			return carried
+		}
+	}
+	return nil
+}
+
+// takeOut takes the item out of the entity's inventory, and off it if it
+// was equipped.
+func takeOut(e *ecs.Entity, id ecs.EntityID) {
+	if inv, ok := e.GetComponentData(inventory); ok {
+		inv.(*Inventory).Remove(id)
+	}
+	if eq, ok := e.GetComponentData(equipment); ok {
+		delete(eq.(*Equipment).Slots, eq.(*Equipment).SlotOf(id))
+	}
+}
+
+// useUp takes one item off the stack the entity carries, getting rid of it
+// when it was the last.
+func useUp(g *Game, e *ecs.Entity, stack *ecs.Entity) {
+	if s, ok := stack.GetComponentData(stackable); ok && s.(*Stackable).Count > 1 {
+		s.(*Stackable).Count--
+		return
+	}
+	takeOut(e, stack.ID)
+	g.World.DisposeEntity(stack)
+}
+
+// throwOne throws one of the stack the entity has equipped to land at x, y.
+// The last one goes itself; otherwise a new one is made from its template.
+func throwOne(g *Game, e *ecs.Entity, stack *ecs.Entity, x int, y int) {
+	s, ok := stack.GetComponentData(stackable)
+	n, named := stack.GetComponentData(name)
+	if ok && named && s.(*Stackable).Count > 1 {
+		if t, ok := ItemTemplateNamed(n.(*Name).Label); ok {
+			s.(*Stackable).Count--
+			SpawnItem(g.World, t, x, y).AddComponent(stackable, &Stackable{Count: 1})
+			return
+		}
+	}
+
+	takeOut(e, stack.ID)
+	stack.AddComponent(position, &Position{X: x, Y: y})
+}
+
+// RangedAttackAction has the actor shoot or throw its ranged weapon at
+// whoever stands on Target.
+type RangedAttackAction struct {
+	Target Position
+}
+
+func (a RangedAttackAction) Perform(g *Game, actor *ecs.QueryResult) ActionResult {
+	pos := actor.Components[position].(*Position)
+	wpn, held := CombatRanged(g, actor.Entity)
+	if wpn == nil {
+		LogMessage(g, "You have nothing to shoot with.")
+		return Failure()
+	}
+
+	defender := ActorAt(g, a.Target.X, a.Target.Y)
+	if defender == nil || !IsHostile(actor, defender) {
+		LogMessage(g, "There is nobody there to shoot at.")
+		return Failure()
+	}
+	if pos.GetChebyshevDistance(&a.Target) > wpn.Range {
+		LogMessage(g, fmt.Sprintf("That is out of range of the %s.", wpn.Name))
+		return Failure()
+	}
+	_, cover, clear := LineOfFire(g, pos, &a.Target)
+	if !clear {
+		LogMessage(g, "You don't have a clear shot.")
+		return Failure()
+	}
+
+	verb := "shoots"
+	switch {
+	case wpn.Thrown:
+		verb = "throws"
+		if held != nil {
+			throwOne(g, actor.Entity, held, a.Target.X, a.Target.Y)
+		}
+	case wpn.Ammo != "" && actor.Entity.HasComponent(inventory):
+		//Monsters with nothing to carry ammunition in never run out
+		ammo := findAmmo(g, actor.Entity, wpn.Ammo)
+		if ammo == nil {
+			LogMessage(g, fmt.Sprintf("You have no %s.", wpn.Ammo))
+			return Failure()
+		}
+		useUp(g, actor.Entity, ammo)
+	}
+
+	cost := ActionCostShoot
+	if result := ResolveAttack(g, actor, defender, &wpn.MeleeWeapon, cover, verb); result.Fumble {
+		cost += fumbleCost
+	}
+	return Success(cost)
+}
+
+// visibleTargets returns where the monsters the player can see and could
+// shoot at stand, nearest first.
+func visibleTargets(g *Game, shooter *ecs.QueryResult) []Position {
+	level := g.Map.CurrentLevel
+	from := shooter.Components[position].(*Position)
+	targets := make([]Position, 0)
+	for _, result := range g.World.Query(g.WorldTags["monsters"]) {
+		pos := result.Components[position].(*Position)
+		if result.Components[health].(*Health).CurrentHealth <= 0 || !level.PlayerCanSee(pos.X, pos.Y) {
+			continue
+		}
+		if IsHostile(shooter, result) {
+			targets = append(targets, *pos)
+		}
+	}
+	sort.SliceStable(targets, func(i, j int) bool {
+		return from.GetChebyshevDistance(&targets[i]) < from.GetChebyshevDistance(&targets[j])
+	})
+	return targets
+}
+
+// StartFiring asks the player what to shoot at, with the cursor on the
+// nearest monster they can see.
+func StartFiring(g *Game) {
+	for _, p := range g.World.Query(g.WorldTags["players"]) {
+		wpn, _ := CombatRanged(g, p.Entity)
+		if wpn == nil {
+			LogMessage(g, "You have nothing to shoot with.")
+			return
+		}
+		if wpn.Ammo != "" && findAmmo(g, p.Entity, wpn.Ammo) == nil {
+			LogMessage(g, fmt.Sprintf("You have no %s.", wpn.Ammo))
+			return
+		}
+
+		pos := p.Components[position].(*Position)
+		t := &Targeting{Kind: TargetTile, Cursor: *pos, Firing: true, Range: wpn.Range}
+		if targets := visibleTargets(g, p); len(targets) > 0 {
+			t.Cursor = targets[0]
+		}
+		g.Targeting = t
+	}
+	g.Input.Clear()
+}
+
+// nextTarget moves the cursor on to the next monster the player can see,
+// going round from the nearest again after the farthest.
+func nextTarget(g *Game) {
+	t := g.Targeting
+	for _, p := range g.World.Query(g.WorldTags["players"]) {
+		targets := visibleTargets(g, p)
+		if len(targets) == 0 {
+			return
+		}
+		next := 0
+		for i, target := range targets {
+			if target.IsEqual(&t.Cursor) {
+				next = (i + 1) % len(targets)
+			}
+		}
+		t.Cursor = targets[next]
+	}
+}
@@ -0,0 +1,139 @@
+package main
+
+import (
+	"fmt"
+	"image/color"
+	"strings"
+
+	"github.com/hajimehoshi/ebiten/v2"
+	"github.com/hajimehoshi/ebiten/v2/inpututil"
+	"github.com/hajimehoshi/ebiten/v2/text"
+	"github.com/hajimehoshi/ebiten/v2/vector"
+)
+
+// RebindScreen is the in-game screen for changing key bindings. It is driven
+// by fixed keys so it keeps working however badly the bindings are mangled:
+// Up/Down pick a command, Enter binds another key to it, Delete clears its
+// keys and Escape saves and leaves.
+type RebindScreen struct {
+	Selected  int
+	Capturing bool
+	Message   string
+}
+
+var conflictColor = color.RGBA{R: 255, G: 90, B: 90, A: 255}
+var selectedColor = color.RGBA{R: 255, G: 220, B: 120, A: 255}
+
+// isModifier reports whether the key is only ever held with other keys.
+func isModifier(k ebiten.Key) bool {
+	switch k {
+	case ebiten.KeyShift, ebiten.KeyShiftLeft, ebiten.KeyShiftRight,
+		ebiten.KeyControl, ebiten.KeyControlLeft, ebiten.KeyControlRight,
+		ebiten.KeyAlt, ebiten.KeyAltLeft, ebiten.KeyAltRight,
+		ebiten.KeyMeta, ebiten.KeyMetaLeft, ebiten.KeyMetaRight:
+		return true
+	}
+	return false
+}
+
+// UpdateRebindScreen handles the input of the rebinding screen.
+func UpdateRebindScreen(g *Game) {
+	rs := g.Rebind
+
+	if rs.Capturing {
+		if inpututil.IsKeyJustPressed(ebiten.KeyEscape) {
+			rs.Capturing = false
+			rs.Message = ""
+			return
+		}
+		for _, k := range inpututil.AppendJustPressedKeys(nil) {
+			if isModifier(k) {
+				continue
+			}
+			binding := KeyBinding{
+				Key:   k,
+				Shift: ebiten.IsKeyPressed(ebiten.KeyShift),
+				Ctrl:  ebiten.IsKeyPressed(ebiten.KeyControl),
+				Alt:   ebiten.IsKeyPressed(ebiten.KeyAlt),
+			}
+			cmd := Commands[rs.Selected]
+			g.Bindings[cmd] = append(g.Bindings[cmd], binding)
+			rs.Capturing = false
+			rs.Message = ""
+			if users, ok := g.Bindings.Conflicts()[binding]; ok {
+				others := make([]string, 0)
+				for _, c := range users {
+					if c != cmd {
+						others = append(others, string(c))
+					}
+				}
+				rs.Message = fmt.Sprintf("%s is also bound to %s", binding, strings.Join(others, ", "))
+			}
+			return
+		}
+		return
+	}
+
+	switch {
+	case inpututil.IsKeyJustPressed(ebiten.KeyUp):
+		rs.Selected = (rs.Selected + len(Commands) - 1) % len(Commands)
+	case inpututil.IsKeyJustPressed(ebiten.KeyDown):
+		rs.Selected = (rs.Selected + 1) % len(Commands)
+	case inpututil.IsKeyJustPressed(ebiten.KeyEnter):
+		rs.Capturing = true
+		rs.Message = "Press the key to bind, Escape to cancel"
+	case inpututil.IsKeyJustPressed(ebiten.KeyDelete), inpututil.IsKeyJustPressed(ebiten.KeyBackspace):
+		g.Bindings[Commands[rs.Selected]] = nil
+		rs.Message = ""
+	case inpututil.IsKeyJustPressed(ebiten.KeyEscape):
+		if err := g.Bindings.Save(g.BindingsPath); err != nil {
+			rs.Message = fmt.Sprintf("Could not save bindings: %v", err)
+			return
+		}
+		g.Rebind = nil
+		g.Input.Clear()
+	}
+}
+
+// DrawRebindScreen draws the rebinding screen over the game.
+func DrawRebindScreen(g *Game, screen *ebiten.Image) {
+	rs := g.Rebind
+	w, h := screen.Bounds().Dx(), screen.Bounds().Dy()
+	vector.DrawFilledRect(screen, 0, 0, float32(w), float32(h), color.RGBA{A: 230}, false)
+
+	conflicts := g.Bindings.Conflicts()
+
+	fontX := 32
+	fontY := 40
+	text.Draw(screen, "Key Bindings", mplusNormalFont, fontX, fontY, color.White)
+	fontY += 16
+	text.Draw(screen, "Up/Down: select   Enter: add key   Delete: clear   Escape: save and close", mplusNormalFont, fontX, fontY, color.White)
+	fontY += 32
+
+	for i, cmd := range Commands {
+		names := make([]string, 0)
+		clr := color.Color(color.White)
+		for _, binding := range g.Bindings[cmd] {
+			names = append(names, binding.String())
+			if _, ok := conflicts[binding]; ok {
+				clr = conflictColor
+			}
+		}
+
+		cursor := "  "
+		if i == rs.Selected {
+			cursor = "> "
+			if clr == color.White {
+				clr = selectedColor
+			}
+		}
+		line := fmt.Sprintf("%s%-18s %s", cursor, cmd, strings.Join(names, ", "))
+		text.Draw(screen, line, mplusNormalFont, fontX, fontY, clr)
+		fontY += 16
+	}
+
+	if rs.Message != "" {
+		fontY += 16
+		text.Draw(screen, rs.Message, mplusNormalFont, fontX, fontY, conflictColor)
+	}
+}
@@ -0,0 +1,21 @@
+package main
+
+// UpdateRegeneration heals the living entities which regenerate for the game
+// turns gone by since they last healed.
+func UpdateRegeneration(g *Game) {
+	now := g.Scheduler.GameTurn()
+	for _, result := range g.World.Query(g.WorldTags["regenerators"]) {
+		regen := result.Components[regeneration].(*Regeneration)
+		hp := result.Components[health].(*Health)
+		if hp.CurrentHealth <= 0 || regen.Turns <= 0 {
+			continue
+		}
+
+		healed := (now - regen.LastTurn) / regen.Turns
+		if healed <= 0 {
+			continue
+		}
+		regen.LastTurn += healed * regen.Turns
+		hp.CurrentHealth = Min(hp.MaxHealth, hp.CurrentHealth+healed)
+	}
+}
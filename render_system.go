@@ -1,20 +1,68 @@
 package main
 
 import (
+	"sort"
+
 	"github.com/hajimehoshi/ebiten/v2"
+	"github.com/hajimehoshi/ebiten/v2/colorm"
+	"github.com/laracarvalho/rogolike/ecs"
 )
 
+// rememberRenderables updates where the player last saw each renderable. A
+// ghost is forgotten once the player can see its tile and the entity isn't
+// on it any more, or once the entity is gone from the world altogether.
+func rememberRenderables(g *Game, level Level, results ecs.QueryResultCollection) {
+	seen := make(map[ecs.EntityID]bool)
+	for _, result := range results {
+		pos := result.Components[position].(*Position)
+		if level.PlayerCanSee(pos.X, pos.Y) {
+			img := result.Components[renderable].(*Renderable).Image
+			level.Ghosts[result.Entity.ID] = &Ghost{X: pos.X, Y: pos.Y, Image: img}
+			seen[result.Entity.ID] = true
+		}
+	}
+
+	for id, ghost := range level.Ghosts {
+		if seen[id] {
+			continue
+		}
+		if level.PlayerCanSee(ghost.X, ghost.Y) || g.World.GetEntityByID(id) == nil {
+			delete(level.Ghosts, id)
+		}
+	}
+}
+
 func ProcessRenderables(g *Game, level Level, screen *ebiten.Image) {
-	for _, result := range g.World.Query(g.WorldTags["renderables"]) {
+	results := g.World.Query(g.WorldTags["renderables"])
+	rememberRenderables(g, level, results)
+
+	//Draw what the player remembers where they can't see any more
+	for _, ghost := range level.Ghosts {
+		if level.PlayerCanSee(ghost.X, ghost.Y) {
+			continue
+		}
+		tile := level.Tiles[level.GetIndexFromXY(ghost.X, ghost.Y)]
+		op := &colorm.DrawImageOptions{}
+		op.GeoM.Translate(float64(tile.PixelX), float64(tile.PixelY))
+		colorm.DrawImage(screen, ghost.Image, rememberedColorM, op)
+	}
+
+	//Items lie on the floor, under whoever stands on them
+	sort.SliceStable(results, func(i, j int) bool {
+		return results[i].Entity.HasComponent(item) && !results[j].Entity.HasComponent(item)
+	})
+	for _, result := range results {
 		pos := result.Components[position].(*Position)
 		img := result.Components[renderable].(*Renderable).Image
 
-		// if level.PlayerVisible.IsVisible(pos.X, pos.Y) {
-		index := level.GetIndexFromXY(pos.X, pos.Y)
-		tile := level.Tiles[index]
-		op := &ebiten.DrawImageOptions{}
-		op.GeoM.Translate(float64(tile.PixelX), float64(tile.PixelY))
-		screen.DrawImage(img, op)
-		// }
+		if level.PlayerCanSee(pos.X, pos.Y) {
+			index := level.GetIndexFromXY(pos.X, pos.Y)
+			tile := level.Tiles[index]
+			op := &ebiten.DrawImageOptions{}
+			op.GeoM.Translate(float64(tile.PixelX), float64(tile.PixelY))
+			r, g, b := level.Light.Tint(pos.X, pos.Y)
+			op.ColorScale.Scale(r, g, b, 1)
+			screen.DrawImage(img, op)
+		}
 	}
 }
@@ -0,0 +1,87 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/laracarvalho/rogolike/ecs"
+	"github.com/laracarvalho/rogolike/fov"
+)
+
+// ghostGame sets up a world with just enough components to render, on a dark
+// corridor the player can only see a tile around them in.
+func ghostGame() (*Game, Level) {
+	engine := ecs.NewEngine()
+	position = engine.NewComponent()
+	renderable = engine.NewComponent()
+	item = engine.NewComponent()
+
+	level := testLevel([]string{"............"})
+	level.PlayerVisible = fov.New()
+	level.Light = NewLightMap()
+	level.Ghosts = make(map[ecs.EntityID]*Ghost)
+
+	g := &Game{
+		World:     engine,
+		WorldTags: map[string]ecs.Tag{"renderables": ecs.BuildTag(renderable, position)},
+	}
+	return g, level
+}
+
+func TestGhostOfMonsterThatDroppedLoot(t *testing.T) {
+	g, level := ghostGame()
+	look := func(x int) {
+		level.PlayerVisible.Compute(level, x, 0, playerSightRadius)
+		rememberRenderables(g, level, g.World.Query(g.WorldTags["renderables"]))
+	}
+
+	skeleton := g.World.NewEntity().
+		AddComponent(renderable, &Renderable{}).
+		AddComponent(position, &Position{X: 1, Y: 0})
+	look(0)
+	if level.Ghosts[skeleton.ID] == nil {
+		t.Fatalf("the player saw the skeleton but doesn't remember it")
+	}
+
+	//The skeleton dies and drops a sword on its tile
+	g.World.DisposeEntity(skeleton)
+	sword := g.World.NewEntity().
+		AddComponent(item, Item{}).
+		AddComponent(renderable, &Renderable{}).
+		AddComponent(position, &Position{X: 1, Y: 0})
+	look(0)
+
+	//The player walks away, out of sight of the tile
+	look(6)
+	if level.Ghosts[skeleton.ID] != nil {
+		t.Errorf("the player still remembers the dead skeleton")
+	}
+	if level.Ghosts[sword.ID] == nil {
+		t.Errorf("the player doesn't remember the sword")
+	}
+}
+
+func TestGhostOfMonsterThatMovedAway(t *testing.T) {
+	g, level := ghostGame()
+	look := func(x int) {
+		level.PlayerVisible.Compute(level, x, 0, playerSightRadius)
+		rememberRenderables(g, level, g.World.Query(g.WorldTags["renderables"]))
+	}
+
+	skeleton := g.World.NewEntity().
+		AddComponent(renderable, &Renderable{}).
+		AddComponent(position, &Position{X: 1, Y: 0})
+	look(0)
+
+	//Another skeleton steps onto the tile as the first walks off into the dark
+	skeleton.AddComponent(position, &Position{X: 5, Y: 0})
+	other := g.World.NewEntity().
+		AddComponent(renderable, &Renderable{}).
+		AddComponent(position, &Position{X: 1, Y: 0})
+	look(0)
+	if ghost := level.Ghosts[skeleton.ID]; ghost != nil {
+		t.Errorf("the player remembers the skeleton at %d, %d, where it can see it isn't", ghost.X, ghost.Y)
+	}
+	if level.Ghosts[other.ID] == nil {
+		t.Errorf("the player doesn't remember the skeleton in front of them")
+	}
+}
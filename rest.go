@@ -0,0 +1,125 @@
+package main
+
+import (
+	"fmt"
+	"image/color"
+	"strconv"
+
+	"github.com/hajimehoshi/ebiten/v2"
+	"github.com/hajimehoshi/ebiten/v2/inpututil"
+	"github.com/hajimehoshi/ebiten/v2/text"
+	"github.com/laracarvalho/rogolike/ecs"
+)
+
+// waitTurns is how many turns the wait_turns command lets go by when the
+// player doesn't type a count.
+const waitTurns = 10
+
+// maxCountDigits is how long a count typed at the wait prompt can be.
+const maxCountDigits = 3
+
+// WaitPrompt asks the player how many turns to wait. Digits type the count,
+// Backspace takes one back, Enter starts waiting and Escape gives up.
+type WaitPrompt struct {
+	Digits string
+}
+
+// OpenWaitPrompt asks the player how many turns to wait.
+func OpenWaitPrompt(g *Game) {
+	g.WaitPrompt = &WaitPrompt{}
+	g.Input.Clear()
+}
+
+// digitKey returns the digit typed with the key, if it is a number key.
+func digitKey(k ebiten.Key) (string, bool) {
+	switch {
+	case k >= ebiten.KeyDigit0 && k <= ebiten.KeyDigit9:
+		return strconv.Itoa(int(k - ebiten.KeyDigit0)), true
+	case k >= ebiten.KeyNumpad0 && k <= ebiten.KeyNumpad9:
+		return strconv.Itoa(int(k - ebiten.KeyNumpad0)), true
+	}
+	return "", false
+}
+
+// UpdateWaitPrompt handles the input of the wait prompt.
+func UpdateWaitPrompt(g *Game) {
+	wp := g.WaitPrompt
+	switch {
+	case inpututil.IsKeyJustPressed(ebiten.KeyEscape):
+		g.WaitPrompt = nil
+		g.Input.Clear()
+		LogMessage(g, "Never mind.")
+		return
+	case inpututil.IsKeyJustPressed(ebiten.KeyEnter) || inpututil.IsKeyJustPressed(ebiten.KeyNumpadEnter):
+		turns := waitTurns
+		if n, err := strconv.Atoi(wp.Digits); err == nil {
+			turns = n
+		}
+		g.WaitPrompt = nil
+		g.Input.Clear()
+		if turns <= 0 {
+			LogMessage(g, "Never mind.")
+			return
+		}
+		StartResting(g, turns, false)
+		return
+	case inpututil.IsKeyJustPressed(ebiten.KeyBackspace) && wp.Digits != "":
+		wp.Digits = wp.Digits[:len(wp.Digits)-1]
+		return
+	}
+
+	for _, k := range inpututil.AppendJustPressedKeys(nil) {
+		if d, ok := digitKey(k); ok && len(wp.Digits) < maxCountDigits {
+			wp.Digits += d
+		}
+	}
+}
+
+// DrawWaitPrompt shows the wait prompt with the count typed so far.
+func DrawWaitPrompt(g *Game, screen *ebiten.Image) {
+	prompt := fmt.Sprintf("Wait how many turns? %s_  (Enter for %d, Escape to cancel)", g.WaitPrompt.Digits, waitTurns)
+	text.Draw(screen, prompt, mplusNormalFont, 16, 20, color.White)
+}
+
+// RestActivity lets turns go by until Turns of them have passed, or with
+// UntilHealed until the player is back to full health. A Turns of 0 means no limit.
+type RestActivity struct {
+	Turns       int
+	UntilHealed bool
+	rested      int
+}
+
+// StartResting sets the player off resting, unless a monster is in view or
+// there is nothing to rest for.
+func StartResting(g *Game, turns int, untilHealed bool) {
+	if len(visibleMonsters(g)) > 0 {
+		LogMessage(g, "Not with monsters in view!")
+		return
+	}
+	if untilHealed && isHealed(g) {
+		LogMessage(g, "You are already at full health.")
+		return
+	}
+	StartActivity(g, &RestActivity{Turns: turns, UntilHealed: untilHealed})
+}
+
+// isHealed reports whether the player is at full health.
+func isHealed(g *Game) bool {
+	for _, p := range g.World.Query(g.WorldTags["players"]) {
+		hp := p.Components[health].(*Health)
+		return hp.CurrentHealth >= hp.MaxHealth
+	}
+	return true
+}
+
+func (r *RestActivity) NextAction(g *Game, player *ecs.QueryResult) Action {
+	if r.UntilHealed && isHealed(g) {
+		StopActivity(g, "You feel rested.")
+		return nil
+	}
+	if r.Turns > 0 && r.rested >= r.Turns {
+		return nil
+	}
+	r.rested++
+	return WaitAction{}
+}
@@ -0,0 +1,118 @@
+package main
+
+import (
+	"container/heap"
+
+	"github.com/laracarvalho/rogolike/ecs"
+)
+
+// Action costs, in units of time at normal speed. An actor with twice the
+// normal speed pays half as much time for the same action.
+const (
+	ActionCostMove        = 100
+	ActionCostAttack      = 100
+	ActionCostHeavyAttack = 150
+	ActionCostWait        = 100
+)
+
+// NormalSpeed is the speed of an ordinary actor.
+const NormalSpeed = 100
+
+// TimePerTurn is the length of one game turn: the time it takes an actor of
+// normal speed to take a step.
+const TimePerTurn = ActionCostMove
+
+// Delay returns how long an actor with the given speed is busy with an action of the given cost.
+func Delay(cost int, speed int) int {
+	if speed <= 0 {
+		speed = 1
+	}
+	return cost * NormalSpeed / speed
+}
+
+// scheduled is an actor waiting in the time queue for its next turn.
+// order breaks ties between actors due at the same time: first come, first served.
+type scheduled struct {
+	ID    ecs.EntityID
+	Time  int
+	order int
+}
+
+type timeQueue []scheduled
+
+func (q timeQueue) Len() int { return len(q) }
+
+func (q timeQueue) Less(i, j int) bool {
+	if q[i].Time == q[j].Time {
+		return q[i].order < q[j].order
+	}
+	return q[i].Time < q[j].Time
+}
+
+func (q timeQueue) Swap(i, j int) { q[i], q[j] = q[j], q[i] }
+
+func (q *timeQueue) Push(x interface{}) {
+	*q = append(*q, x.(scheduled))
+}
+
+func (q *timeQueue) Pop() interface{} {
+	old := *q
+	s := old[len(old)-1]
+	*q = old[:len(old)-1]
+	return s
+}
+
+// Scheduler decides who acts next. Every actor waits in a time queue for the
+// moment its last action is done; the one due soonest acts next, and the clock
+// jumps forward to that moment.
+type Scheduler struct {
+	Now   int
+	queue timeQueue
+	order int
+}
+
+// NewScheduler creates an empty scheduler at time zero.
+func NewScheduler() *Scheduler {
+	return &Scheduler{}
+}
+
+// Add queues the actor to act delay time units from now.
+func (s *Scheduler) Add(id ecs.EntityID, delay int) {
+	s.order++
+	heap.Push(&s.queue, scheduled{ID: id, Time: s.Now + delay, order: s.order})
+}
+
+// Peek returns the actor due to act next without taking it off the queue.
+func (s *Scheduler) Peek() (ecs.EntityID, bool) {
+	if len(s.queue) == 0 {
+		return 0, false
+	}
+	return s.queue[0].ID, true
+}
+
+// Next takes the actor due to act next off the queue and moves the clock to its turn.
+func (s *Scheduler) Next() (ecs.EntityID, bool) {
+	if len(s.queue) == 0 {
+		return 0, false
+	}
+	next := heap.Pop(&s.queue).(scheduled)
+	if next.Time > s.Now {
+		s.Now = next.Time
+	}
+	return next.ID, true
+}
+
+// Remove takes the actor off the queue, if it is in it.
+func (s *Scheduler) Remove(id ecs.EntityID) {
+	for i, entry := range s.queue {
+		if entry.ID == id {
+			heap.Remove(&s.queue, i)
+			return
+		}
+	}
+}
+
+// GameTurn returns the number of whole game turns that have gone by.
+func (s *Scheduler) GameTurn() int {
+	return s.Now / TimePerTurn
+}
@@ -0,0 +1,166 @@
+package main
+
+import (
+	"fmt"
+	"image/color"
+
+	"github.com/laracarvalho/rogolike/ecs"
+)
+
+// StatusKind is a kind of status effect.
+type StatusKind int
+
+const (
+	Poisoned StatusKind = iota
+	Burning
+	Stunned
+	Slowed
+	Regenerating
+	Blessed
+)
+
+// StatusKinds lists the status effects in the order they are shown.
+var StatusKinds = []StatusKind{Poisoned, Burning, Stunned, Slowed, Regenerating, Blessed}
+
+func (k StatusKind) String() string {
+	switch k {
+	case Poisoned:
+		return "poisoned"
+	case Burning:
+		return "burning"
+	case Stunned:
+		return "stunned"
+	case Slowed:
+		return "slowed"
+	case Regenerating:
+		return "regenerating"
+	case Blessed:
+		return "blessed"
+	}
+	return "unknown"
+}
+
+// component returns the component an entity under the status effect has.
+func (k StatusKind) component() *ecs.Component {
+	switch k {
+	case Poisoned:
+		return poisoned
+	case Burning:
+		return burning
+	case Stunned:
+		return stunned
+	case Slowed:
+		return slowed
+	case Regenerating:
+		return regenerating
+	}
+	return blessed
+}
+
+// StackRule is what happens when a status effect is put on an entity which
+// is already under it.
+type StackRule int
+
+const (
+	// StackRefresh keeps the longer of the two durations and the stronger power.
+	StackRefresh StackRule = iota
+	// StackDuration adds the durations together.
+	StackDuration
+	// StackIntensity adds the powers together and keeps the longer duration.
+	StackIntensity
+	// StackNone ignores the new one until the old one wears off.
+	StackNone
+)
+
+// statusInfo is how a kind of status effect stacks and how it is shown on
+// the HUD: a square of Color marked with Letter.
+type statusInfo struct {
+	Stacking StackRule
+	Letter   string
+	Color    color.RGBA
+}
+
+var statusInfos = map[StatusKind]statusInfo{
+	Poisoned:     {Stacking: StackIntensity, Letter: "P", Color: color.RGBA{R: 80, G: 190, B: 60, A: 255}},
+	Burning:      {Stacking: StackRefresh, Letter: "B", Color: color.RGBA{R: 240, G: 110, B: 30, A: 255}},
+	Stunned:      {Stacking: StackNone, Letter: "S", Color: color.RGBA{R: 230, G: 220, B: 90, A: 255}},
+	Slowed:       {Stacking: StackRefresh, Letter: "W", Color: color.RGBA{R: 90, G: 140, B: 230, A: 255}},
+	Regenerating: {Stacking: StackDuration, Letter: "R", Color: color.RGBA{R: 230, G: 80, B: 120, A: 255}},
+	Blessed:      {Stacking: StackRefresh, Letter: "+", Color: color.RGBA{R: 250, G: 240, B: 200, A: 255}},
+}
+
+// StatusOf returns the status effect of the kind the entity is under, if it is.
+func StatusOf(e *ecs.Entity, kind StatusKind) (*Status, bool) {
+	s, ok := e.GetComponentData(kind.component())
+	if !ok {
+		return nil, false
+	}
+	return s.(*Status), true
+}
+
+// statusPower returns the power of the status effect of the kind the entity
+// is under, or 0.
+func statusPower(e *ecs.Entity, kind StatusKind) int {
+	if s, ok := StatusOf(e, kind); ok {
+		return s.Power
+	}
+	return 0
+}
+
+// IsImmune reports whether the entity is immune to the kind of status effect.
+func IsImmune(e *ecs.Entity, kind StatusKind) bool {
+	if r, ok := e.GetComponentData(resistances); ok {
+		return r.(*Resistances).Statuses[kind]
+	}
+	return false
+}
+
+// InflictStatus puts the status effect on the living target for turns game
+// turns, stacking it with any it is already under, and reports whether it
+// took hold.
+func InflictStatus(g *Game, target *ecs.QueryResult, kind StatusKind, turns int, power int) bool {
+	label := target.Components[name].(*Name).Label
+	if target.Components[health].(*Health).CurrentHealth <= 0 || turns <= 0 {
+		return false
+	}
+	if IsImmune(target.Entity, kind) {
+		LogMessage(g, fmt.Sprintf("The %s is not %s.", label, kind))
+		return false
+	}
+
+	old, ok := StatusOf(target.Entity, kind)
+	if !ok {
+		target.Entity.AddComponent(kind.component(), &Status{
+			Turns:    turns,
+			Power:    power,
+			LastTurn: g.Scheduler.GameTurn(),
+		})
+		LogMessage(g, fmt.Sprintf("The %s is %s!", label, kind))
+		return true
+	}
+
+	switch statusInfos[kind].Stacking {
+	case StackRefresh:
+		old.Turns = Max(old.Turns, turns)
+		old.Power = Max(old.Power, power)
+	case StackDuration:
+		old.Turns += turns
+	case StackIntensity:
+		old.Turns = Max(old.Turns, turns)
+		old.Power += power
+	case StackNone:
+		return false
+	}
+	return true
+}
+
+// StatusEffect puts a status effect on the user of an item.
+type StatusEffect struct {
+	Kind  StatusKind
+	Turns int
+	Power int
+}
+
+func (e StatusEffect) Apply(g *Game, user *ecs.QueryResult, target Target) {
+	InflictStatus(g, user, e.Kind, e.Turns, e.Power)
+}
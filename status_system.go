@@ -0,0 +1,57 @@
+package main
+
+import (
+	"fmt"
+
+	"github.com/laracarvalho/rogolike/ecs"
+)
+
+// UpdateStatuses ticks the status effects of the living players and monsters
+// once for each game turn gone by since they last ticked, and takes off those
+// which have worn off.
+func UpdateStatuses(g *Game) {
+	now := g.Scheduler.GameTurn()
+	for _, tag := range []string{"players", "monsters"} {
+		for _, result := range g.World.Query(g.WorldTags[tag]) {
+			for _, kind := range StatusKinds {
+				s, ok := StatusOf(result.Entity, kind)
+				if !ok {
+					continue
+				}
+				for s.LastTurn < now && s.Turns > 0 {
+					if result.Components[health].(*Health).CurrentHealth <= 0 {
+						break
+					}
+					s.LastTurn++
+					s.Turns--
+					tickStatus(g, result, kind, s)
+				}
+				if s.Turns <= 0 {
+					result.Entity.RemoveComponent(kind.component())
+					LogMessage(g, fmt.Sprintf("The %s is no longer %s.", result.Components[name].(*Name).Label, kind))
+				}
+			}
+		}
+	}
+}
+
+// tickStatus does what the status effect does to the entity each game turn.
+// Stuns, slows and blessings work on its turns and attacks instead.
+func tickStatus(g *Game, result *ecs.QueryResult, kind StatusKind, s *Status) {
+	label := result.Components[name].(*Name).Label
+	switch kind {
+	case Poisoned:
+		resolved, _ := DealDamage(g, result, Damage{Amount: s.Power, Type: Poison})
+		if resolved.Taken > 0 {
+			LogMessage(g, fmt.Sprintf("The %s takes %d poison damage.", label, resolved.Taken))
+		}
+	case Burning:
+		resolved, _ := DealDamage(g, result, Damage{Amount: s.Power, Type: Fire})
+		if resolved.Taken > 0 {
+			LogMessage(g, fmt.Sprintf("The %s burns for %d health.", label, resolved.Taken))
+		}
+	case Regenerating:
+		hp := result.Components[health].(*Health)
+		hp.CurrentHealth = Min(hp.MaxHealth, hp.CurrentHealth+s.Power)
+	}
+}
@@ -0,0 +1,187 @@
+package main
+
+import (
+	"image/color"
+
+	"github.com/hajimehoshi/ebiten/v2"
+	"github.com/hajimehoshi/ebiten/v2/inpututil"
+	"github.com/hajimehoshi/ebiten/v2/text"
+	"github.com/hajimehoshi/ebiten/v2/vector"
+	"github.com/laracarvalho/rogolike/ecs"
+)
+
+var targetColor = color.RGBA{R: 255, G: 220, B: 120, A: 255}
+var areaColor = color.RGBA{R: 255, G: 120, B: 40, A: 60}
+var blockedColor = color.RGBA{R: 200, G: 40, B: 40, A: 90}
+
+// Targeting is the prompt for where to aim an item. The movement keys move
+// the cursor, or pick the direction for items aimed in a direction; Enter or
+// a click fires and Escape gives up. When Firing, the player is aiming their
+// ranged weapon, which reaches Range tiles, rather than an item, and Tab
+// moves the cursor from one monster in sight to the next.
+type Targeting struct {
+	Item   ecs.EntityID
+	Kind   TargetKind
+	Radius int
+	Cursor Position
+	Firing bool
+	Range  int
+}
+
+// UseItem uses the item, asking the player where to aim it first if it needs aiming.
+func UseItem(g *Game, id ecs.EntityID) {
+	used := g.World.GetEntityByID(id)
+	if used == nil {
+		return
+	}
+	c, ok := used.Entity.GetComponentData(consumable)
+	if !ok || c.(*Consumable).Targeting == TargetNone {
+		g.queuedAction = UseItemAction{Item: id}
+		return
+	}
+	if c.(*Consumable).Targeting == TargetItem {
+		OpenInventory(g, InventoryTarget)
+		g.Inventory.Using = id
+		return
+	}
+
+	for _, p := range g.World.Query(g.WorldTags["players"]) {
+		pos := p.Components[position].(*Position)
+		g.Targeting = &Targeting{
+			Item:   id,
+			Kind:   c.(*Consumable).Targeting,
+			Radius: c.(*Consumable).Radius,
+			Cursor: *pos,
+		}
+	}
+	g.Input.Clear()
+}
+
+// UpdateTargeting handles the input of the targeting prompt.
+func UpdateTargeting(g *Game) {
+	t := g.Targeting
+	level := g.Map.CurrentLevel
+
+	if inpututil.IsKeyJustPressed(ebiten.KeyEscape) {
+		g.Targeting = nil
+		g.Input.Clear()
+		LogMessage(g, "Never mind.")
+		return
+	}
+
+	if t.Firing && inpututil.IsKeyJustPressed(ebiten.KeyTab) {
+		nextTarget(g)
+		return
+	}
+
+	if t.Kind == TargetTile {
+		if x, y, ok := cursorTile(); ok && inpututil.IsMouseButtonJustPressed(ebiten.MouseButtonLeft) {
+			t.Cursor = Position{X: x, Y: y}
+			confirmTarget(g)
+			return
+		}
+		if inpututil.IsKeyJustPressed(ebiten.KeyEnter) {
+			confirmTarget(g)
+			return
+		}
+	}
+
+	cmd, ok := g.Input.Next()
+	if !ok {
+		return
+	}
+	if t.Firing && cmd == CommandFire {
+		nextTarget(g)
+		return
+	}
+	dir, ok := commandDirections[cmd]
+	if !ok {
+		return
+	}
+	if dir.X != 0 && dir.Y != 0 && !g.Movement.Diagonal {
+		return
+	}
+
+	if t.Kind == TargetDirection {
+		for _, p := range g.World.Query(g.WorldTags["players"]) {
+			pos := p.Components[position].(*Position)
+			t.Cursor = Position{X: pos.X + dir.X, Y: pos.Y + dir.Y}
+		}
+		confirmTarget(g)
+		return
+	}
+	if level.InBounds(t.Cursor.X+dir.X, t.Cursor.Y+dir.Y) {
+		t.Cursor.X += dir.X
+		t.Cursor.Y += dir.Y
+	}
+}
+
+// confirmTarget uses the item, or fires, at the cursor if the player can see it.
+func confirmTarget(g *Game) {
+	t := g.Targeting
+	if t.Kind == TargetTile && !g.Map.CurrentLevel.PlayerCanSee(t.Cursor.X, t.Cursor.Y) {
+		LogMessage(g, "You can't see there.")
+		return
+	}
+	if t.Firing {
+		g.queuedAction = RangedAttackAction{Target: t.Cursor}
+	} else {
+		g.queuedAction = UseItemAction{Item: t.Item, Target: Target{Tile: t.Cursor}}
+	}
+	g.Targeting = nil
+	g.Input.Clear()
+}
+
+// DrawTargeting marks the tile being aimed at, and the area the item will
+// reach or the line a shot would fly along.
+func DrawTargeting(g *Game, screen *ebiten.Image) {
+	t := g.Targeting
+	gd := NewGameData()
+	tw := float32(gd.TileWidth)
+	th := float32(gd.TileHeight)
+
+	prompt := "Aim with the movement keys, Enter or click to fire, Escape to cancel"
+	if t.Kind == TargetDirection {
+		prompt = "Which direction? Escape to cancel"
+	}
+	if t.Firing {
+		prompt = "Aim with the movement keys or Tab, Enter or click to fire, Escape to cancel"
+	}
+	text.Draw(screen, prompt, mplusNormalFont, 16, 20, color.White)
+	if t.Kind != TargetTile {
+		return
+	}
+
+	if t.Firing {
+		drawLineOfFire(g, screen)
+	}
+	for y := t.Cursor.Y - t.Radius; y <= t.Cursor.Y+t.Radius; y++ {
+		for x := t.Cursor.X - t.Radius; x <= t.Cursor.X+t.Radius; x++ {
+			if g.Map.CurrentLevel.InBounds(x, y) {
+				vector.DrawFilledRect(screen, float32(x)*tw, float32(y)*th, tw, th, areaColor, false)
+			}
+		}
+	}
+	vector.StrokeRect(screen, float32(t.Cursor.X)*tw, float32(t.Cursor.Y)*th, tw, th, 2, targetColor, false)
+}
+
+// drawLineOfFire shades the tiles a shot at the cursor would fly through, in
+// red from where it would be stopped or out of range.
+func drawLineOfFire(g *Game, screen *ebiten.Image) {
+	t := g.Targeting
+	gd := NewGameData()
+	tw := float32(gd.TileWidth)
+	th := float32(gd.TileHeight)
+
+	for _, p := range g.World.Query(g.WorldTags["players"]) {
+		pos := p.Components[position].(*Position)
+		path, _, clear := LineOfFire(g, pos, &t.Cursor)
+		for i, tile := range path[1:] {
+			clr := areaColor
+			if i+1 > t.Range || (!clear && i == len(path)-2) {
+				clr = blockedColor
+			}
+			vector.DrawFilledRect(screen, float32(tile.X)*tw, float32(tile.Y)*th, tw, th, clr, false)
+		}
+	}
+}
@@ -9,17 +9,27 @@ const (
 	GameOver
 )
 
-func GetNextState(state TurnState) TurnState {
-	switch state {
-	case BeforePlayerAction:
-		return PlayerTurn
-	case PlayerTurn:
-		return MonsterTurn
-	case MonsterTurn:
-		return BeforePlayerAction
-	case GameOver:
+// GetNextState asks the scheduler who acts next and returns the matching state.
+// Actors which have been disposed of since they were queued are dropped on the way.
+func GetNextState(g *Game) TurnState {
+	if g.Turn == GameOver {
 		return GameOver
-	default:
-		return PlayerTurn
+	}
+
+	for {
+		id, ok := g.Scheduler.Peek()
+		if !ok {
+			return PlayerTurn
+		}
+
+		actor := g.World.GetEntityByID(id)
+		if actor == nil {
+			g.Scheduler.Next()
+			continue
+		}
+		if actor.Entity.HasComponent(player) {
+			return PlayerTurn
+		}
+		return MonsterTurn
 	}
 }
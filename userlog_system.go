@@ -14,7 +14,34 @@ import (
 var userLogImg *ebiten.Image = nil
 var err error = nil
 var mplusNormalFont font.Face = nil
-var lastText []string = make([]string, 0, 5)
+var messageLog []string = make([]string, 0, 100)
+var logScroll int = 0
+
+// logLines is how many messages fit in the log at once.
+const logLines = 8
+
+// maxLogLength is how many messages the log keeps to scroll back through.
+const maxLogLength = 200
+
+// ScrollUserLog moves the log back (positive lines) or forward (negative)
+// through the message history.
+func ScrollUserLog(lines int) {
+	logScroll += lines
+	if logScroll > len(messageLog)-logLines {
+		logScroll = len(messageLog) - logLines
+	}
+	if logScroll < 0 {
+		logScroll = 0
+	}
+}
+
+// LogMessage shows a message to the player in the log.
+func LogMessage(g *Game, msg string) {
+	for _, p := range g.World.Query(g.WorldTags["players"]) {
+		m := p.Components[userMessage].(*UserMessage)
+		m.Messages = append(m.Messages, msg)
+	}
+}
 
 func ProcessUserLog(g *Game, screen *ebiten.Image) {
 	if mplusNormalFont == nil {
@@ -45,6 +72,11 @@ func ProcessUserLog(g *Game, screen *ebiten.Image) {
 
 	for _, m := range g.World.Query(g.WorldTags["messengers"]) {
 		messages := m.Components[userMessage].(*UserMessage)
+		if len(messages.Messages) > 0 {
+			tmpMessages = append(tmpMessages, messages.Messages...)
+			anyMessages = true
+			messages.Messages = messages.Messages[:0]
+		}
 		if messages.AttackMessage != "" {
 			tmpMessages = append(tmpMessages, messages.AttackMessage)
 			anyMessages = true
@@ -64,14 +96,23 @@ func ProcessUserLog(g *Game, screen *ebiten.Image) {
 		if messages.GameStateMessage != "" {
 			tmpMessages = append(tmpMessages, messages.GameStateMessage)
 			anyMessages = true
-			//No need to clear, it's all over
+			//It's all over, but it only needs saying once
+			messages.GameStateMessage = ""
 		}
 
 	}
 	if anyMessages {
-		lastText = tmpMessages
+		messageLog = append(messageLog, tmpMessages...)
+		if len(messageLog) > maxLogLength {
+			messageLog = messageLog[len(messageLog)-maxLogLength:]
+		}
+		//Jump back to the newest messages
+		logScroll = 0
 	}
-	for _, msg := range lastText {
+
+	end := len(messageLog) - logScroll
+	start := Max(0, end-logLines)
+	for _, msg := range messageLog[start:end] {
 		if msg != "" {
 			text.Draw(screen, msg, mplusNormalFont, fontX, fontY, color.White)
 			fontY += 16
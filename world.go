@@ -7,6 +7,7 @@ import (
 	"github.com/laracarvalho/rogolike/ecs"
 )
 
+var player *ecs.Component
 var position *ecs.Component
 var renderable *ecs.Component
 var monster *ecs.Component
@@ -15,12 +16,44 @@ var meleeWeapon *ecs.Component
 var armor *ecs.Component
 var name *ecs.Component
 var userMessage *ecs.Component
+var lightSource *ecs.Component
+var speed *ecs.Component
+var regeneration *ecs.Component
+var item *ecs.Component
+var stackable *ecs.Component
+var weight *ecs.Component
+var inventory *ecs.Component
+var equippable *ecs.Component
+var equipment *ecs.Component
+var consumable *ecs.Component
+var drops *ecs.Component
+var rarity *ecs.Component
+var unidentified *ecs.Component
+var resistances *ecs.Component
+var rangedWeapon *ecs.Component
+var poisoned *ecs.Component
+var burning *ecs.Component
+var stunned *ecs.Component
+var slowed *ecs.Component
+var regenerating *ecs.Component
+var blessed *ecs.Component
+var experience *ecs.Component
+var xpValue *ecs.Component
 
-func InitializeWorld(startLevel Level) (*ecs.Engine, map[string]ecs.Tag) {
+// braziers is one in how many rooms gets a brazier to light it.
+const braziers = 3
+
+// glowingSkeletons is one in how many skeletons glows in the dark.
+const glowingSkeletons = 3
+
+// skeletonArchers is one in how many skeletons shoots with a bow.
+const skeletonArchers = 3
+
+func InitializeWorld(startLevel Level, loot *Loot) (*ecs.Engine, map[string]ecs.Tag) {
 	tags := make(map[string]ecs.Tag)
 	engine := ecs.NewEngine()
 
-	player := engine.NewComponent()
+	player = engine.NewComponent()
 	position = engine.NewComponent()
 	renderable = engine.NewComponent()
 	movable := engine.NewComponent()
@@ -30,6 +63,29 @@ func InitializeWorld(startLevel Level) (*ecs.Engine, map[string]ecs.Tag) {
 	armor = engine.NewComponent()
 	name = engine.NewComponent()
 	userMessage = engine.NewComponent()
+	lightSource = engine.NewComponent()
+	speed = engine.NewComponent()
+	regeneration = engine.NewComponent()
+	item = engine.NewComponent()
+	stackable = engine.NewComponent()
+	weight = engine.NewComponent()
+	inventory = engine.NewComponent()
+	equippable = engine.NewComponent()
+	equipment = engine.NewComponent()
+	consumable = engine.NewComponent()
+	drops = engine.NewComponent()
+	rarity = engine.NewComponent()
+	unidentified = engine.NewComponent()
+	resistances = engine.NewComponent()
+	rangedWeapon = engine.NewComponent()
+	poisoned = engine.NewComponent()
+	burning = engine.NewComponent()
+	stunned = engine.NewComponent()
+	slowed = engine.NewComponent()
+	regenerating = engine.NewComponent()
+	blessed = engine.NewComponent()
+	experience = engine.NewComponent()
+	xpValue = engine.NewComponent()
 
 	startRoom := startLevel.Rooms[0]
 	x, y := startRoom.Center()
@@ -39,10 +95,11 @@ func InitializeWorld(startLevel Level) (*ecs.Engine, map[string]ecs.Tag) {
 		log.Fatal(playerErr)
 	}
 
-	skellyImg, _, skellyErr := ebitenutil.NewImageFromFile("assets/skelly.png")
-	if skellyErr != nil {
-		log.Fatal(skellyErr)
-	}
+	axe := CreateItem(engine, starterWeapon)
+	plate := CreateItem(engine, starterArmor)
+	gear := NewEquipment()
+	gear.Slots[SlotMainHand] = axe.ID
+	gear.Slots[SlotBody] = plate.ID
 
 	engine.NewEntity().
 		AddComponent(player, Player{}).
@@ -58,28 +115,68 @@ func InitializeWorld(startLevel Level) (*ecs.Engine, map[string]ecs.Tag) {
 			MaxHealth:     30,
 			CurrentHealth: 30,
 		}).
-		AddComponent(meleeWeapon, &MeleeWeapon{
-			Name:          "Battle Axe",
-			MinimumDamage: 10,
-			MaximumDamage: 20,
-			ToHitBonus:    3,
-		}).
-		AddComponent(armor, &Armor{
-			Name:       "Plate Armor",
-			Defense:    15,
-			ArmorClass: 18,
-		}).
 		AddComponent(name, &Name{Label: "Player"}).
+		AddComponent(speed, &Speed{Speed: NormalSpeed}).
+		AddComponent(regeneration, &Regeneration{Turns: 6}).
+		AddComponent(inventory, &Inventory{
+			Items:     []ecs.EntityID{axe.ID, plate.ID},
+			MaxItems:  26,
+			MaxWeight: 60,
+		}).
+		AddComponent(equipment, gear).
+		AddComponent(experience, &Experience{Level: 1}).
 		AddComponent(userMessage, &UserMessage{
 			AttackMessage:    "",
 			DeadMessage:      "",
 			GameStateMessage: "",
+		}).
+		AddComponent(lightSource, &LightSource{
+			Radius:  4,
+			Color:   torchLight,
+			Flicker: 0.15,
 		})
 
-	for _, room := range startLevel.Rooms {
+	PopulateLevel(engine, startLevel, loot)
+
+	players := ecs.BuildTag(player, position, health, name, userMessage, speed)
+	tags["players"] = players
+
+	renderables := ecs.BuildTag(renderable, position)
+	tags["renderables"] = renderables
+
+	monsters := ecs.BuildTag(monster, position, health, meleeWeapon, armor, name, userMessage, speed)
+	tags["monsters"] = monsters
+
+	messengers := ecs.BuildTag(userMessage)
+	tags["messengers"] = messengers
+
+	lights := ecs.BuildTag(lightSource, position)
+	tags["lights"] = lights
+
+	items := ecs.BuildTag(item, position)
+	tags["items"] = items
+
+	regenerators := ecs.BuildTag(regeneration, health)
+	tags["regenerators"] = regenerators
+
+	return engine, tags
+}
+
+// PopulateLevel spawns the monsters, braziers and floor loot of a level. The
+// first room, where the player arrives, is left free of monsters and is
+// always lit.
+func PopulateLevel(engine *ecs.Engine, level Level, loot *Loot) {
+	startRoom := level.Rooms[0]
+
+	skellyImg, _, skellyErr := ebitenutil.NewImageFromFile("assets/skelly.png")
+	if skellyErr != nil {
+		log.Fatal(skellyErr)
+	}
+
+	for _, room := range level.Rooms {
 		if room.X != startRoom.X {
 			mX, mY := room.Center()
-			engine.NewEntity().
+			skelly := engine.NewEntity().
 				AddComponent(monster, &Monster{}).
 				AddComponent(renderable, &Renderable{
 					Image: skellyImg,
@@ -104,25 +201,53 @@ func InitializeWorld(startLevel Level) (*ecs.Engine, map[string]ecs.Tag) {
 					ArmorClass: 4,
 				}).
 				AddComponent(name, &Name{Label: "Skeleton"}).
+				AddComponent(speed, &Speed{Speed: NormalSpeed}).
+				AddComponent(drops, &Drops{Table: skeletonLoot}).
+				AddComponent(resistances, skeletonResistances.Clone()).
+				AddComponent(xpValue, &XPValue{XP: MonsterXP(10, level.Depth)}).
 				AddComponent(userMessage, &UserMessage{
 					AttackMessage:    "",
 					DeadMessage:      "",
 					GameStateMessage: "",
 				})
+			if GetDiceRoll(glowingSkeletons) == 1 {
+				skelly.AddComponent(lightSource, &LightSource{
+					Radius: 2,
+					Color:  graveLight,
+				})
+				//Glowing skeletons always leave a potion behind
+				skelly.AddComponent(drops, &Drops{Table: skeletonLoot, Always: []string{"Potion of Healing"}})
+			}
+			if GetDiceRoll(skeletonArchers) == 1 {
+				skelly.AddComponent(name, &Name{Label: "Skeleton Archer"})
+				skelly.AddComponent(xpValue, &XPValue{XP: MonsterXP(12, level.Depth)})
+				skelly.AddComponent(rangedWeapon, &RangedWeapon{
+					MeleeWeapon: MeleeWeapon{
+						Name:          "Short Bow",
+						MinimumDamage: 1,
+						MaximumDamage: 4,
+						DamageType:    Piercing,
+					},
+					Range: 6,
+				})
+			}
 		}
-	}
 
-	players := ecs.BuildTag(player, position, health, meleeWeapon, armor, name, userMessage)
-	tags["players"] = players
-
-	renderables := ecs.BuildTag(renderable, position)
-	tags["renderables"] = renderables
-
-	monsters := ecs.BuildTag(monster, position, health, meleeWeapon, armor, name, userMessage)
-	tags["monsters"] = monsters
-
-	messengers := ecs.BuildTag(userMessage)
-	tags["messengers"] = messengers
+		if room.X == startRoom.X || GetDiceRoll(braziers) == 1 {
+			//Light the room from one of its corners
+			engine.NewEntity().
+				AddComponent(position, &Position{
+					X: room.X + 1,
+					Y: room.Y + 1,
+				}).
+				AddComponent(name, &Name{Label: "Brazier"}).
+				AddComponent(lightSource, &LightSource{
+					Radius:  7,
+					Color:   torchLight,
+					Flicker: 0.1,
+				})
+		}
+	}
 
-	return engine, tags
+	PlaceFloorLoot(engine, level, loot)
 }